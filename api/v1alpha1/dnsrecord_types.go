@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types and reasons reported on DNSRecord status.
+const (
+	ConditionReady = "Ready"
+
+	ReasonApplied = "Applied" // the record was written to the DNS provider
+	ReasonFailed  = "Failed"  // the DNS provider rejected the record or could not be reached
+	ReasonInvalid = "Invalid" // the spec cannot be applied as written
+)
+
+// DNSRecordSpec declares a single DNS record.
+type DNSRecordSpec struct {
+	// Hostname is the fully qualified name of the record, e.g. "legacy.example.com".
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="hostname is immutable"
+	Hostname string `json:"hostname"`
+
+	// Type is the record type, e.g. "A", "AAAA", "CNAME" or "TXT".
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="type is immutable"
+	Type string `json:"type"`
+
+	// Values are the record values, e.g. IP addresses or a CNAME target.
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
+
+	// TTL in seconds. 0 uses the provider default.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTL int `json:"ttl,omitempty"`
+}
+
+// DNSRecordStatus reports whether the record has been applied.
+type DNSRecordStatus struct {
+	// ObservedGeneration is the generation last processed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the state of the record. The Ready condition is
+	// False with the provider error as its message when applying failed.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DNSRecord declares a DNS record that is not derived from a route, e.g. a
+// CNAME for a legacy host or a TXT record.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Hostname",type=string,JSONPath=`.spec.hostname`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type DNSRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DNSRecordSpec   `json:"spec"`
+	Status DNSRecordStatus `json:"status,omitempty"`
+}
+
+// DNSRecordList contains a list of DNSRecord.
+// +kubebuilder:object:root=true
+type DNSRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DNSRecord `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DNSRecord{}, &DNSRecordList{})
+}
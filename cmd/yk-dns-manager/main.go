@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -14,7 +18,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
+	dnsv1alpha1 "github.com/yuriy-kovalchuk/yk-dns-manager/api/v1alpha1"
 	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
 	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/controller"
 	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
@@ -26,9 +32,15 @@ var (
 	Version = "dev"
 )
 
+// managedRecordsInterval is how often managed records are counted when the
+// drift resync, which counts them otherwise, is disabled.
+const managedRecordsInterval = 5 * time.Minute
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(gatewayv1.Install(scheme))
+	utilruntime.Must(gatewayv1alpha2.Install(scheme))
+	utilruntime.Must(dnsv1alpha1.AddToScheme(scheme))
 }
 
 func main() {
@@ -56,27 +68,40 @@ func run() error {
 	if domainMapPath == "" {
 		domainMapPath = "configs/domain-map.yaml"
 	}
-	domainMap, err := config.LoadDomainMap(domainMapPath)
-	if err != nil {
-		return fmt.Errorf("unable to load domain map: %w", err)
-	}
-	log.Info("loaded domain map", "path", domainMapPath)
 
-	providerCfg, err := config.LoadProviderConfig()
+	providerPath := config.ProviderConfigPath()
+	providerCfg, err := config.LoadProviderConfigFromPath(providerPath)
 	if err != nil {
 		return fmt.Errorf("unable to load provider config: %w", err)
 	}
-	log.Info("loaded provider config", "provider", providerCfg.Provider)
+	log.Info("loaded provider config", "provider", providerCfg.Provider, "valueSource", providerCfg.ValueSource,
+		"routeKinds", providerCfg.RouteKinds)
 
-	dnsProvider, err := dns.NewProvider(providerCfg.Provider, ctrl.Log.WithName("dns-"+providerCfg.Provider), providerCfg.Settings)
-	if err != nil {
-		return fmt.Errorf("unable to create DNS provider: %w", err)
+	domainMap, err := config.LoadDomainMap(domainMapPath)
+	switch {
+	case err == nil:
+		log.Info("loaded domain map", "path", domainMapPath)
+	case errors.Is(err, fs.ErrNotExist) && providerCfg.ValueSource == config.ValueSourceGateway:
+		// With Gateway addresses as the value source the domain map is optional.
+		domainMap = nil
+		log.Info("no domain map found, using Gateway addresses only", "path", domainMapPath)
+	default:
+		return fmt.Errorf("unable to load domain map: %w", err)
 	}
 
 	log.Info("checking DNS provider connectivity")
-	if err := dnsProvider.HealthCheck(ctx); err != nil {
-		return fmt.Errorf("DNS provider health check failed: %w", err)
+	provider, err := newProvider(ctx, providerCfg)
+	if err != nil {
+		return err
+	}
+	// Reloading the provider config swaps the provider underneath every controller.
+	dnsProvider := dns.NewSwappable(provider)
+	zones, err := dns.NewZones(providerCfg.ZoneNames(domainMap)...)
+	if err != nil {
+		return fmt.Errorf("unable to load DNS zones: %w", err)
 	}
+	dnsProvider.SetZones(zones)
+	log.Info("loaded DNS zones", "zones", zones.Names())
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -93,17 +118,116 @@ func run() error {
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		return fmt.Errorf("unable to set up ready check: %w", err)
 	}
+	// Rejected credentials make the controller report itself not ready.
+	providerHealth := &controller.ProviderHealth{DNS: dnsProvider}
+	if err := mgr.AddReadyzCheck("dns-provider", providerHealth.Check); err != nil {
+		return fmt.Errorf("unable to set up DNS provider ready check: %w", err)
+	}
 
-	reconciler := &controller.HTTPRouteReconciler{
-		Client:    mgr.GetClient(),
-		APIReader: mgr.GetAPIReader(),
-		Log:       ctrl.Log.WithName("httproute-controller"),
+	resolver := &controller.Resolver{
+		Reader:    mgr.GetClient(),
 		DomainMap: domainMap,
-		DNS:       dnsProvider,
-		Upsert:    providerCfg.Upsert,
+		Source:    providerCfg.ValueSource,
+		Allowlist: providerCfg.DomainMapMode == config.DomainMapAllowlist,
+	}
+
+	routeKinds, err := controller.RouteKindsByName(providerCfg.RouteKinds, providerCfg.IngressClass)
+	if err != nil {
+		return fmt.Errorf("unable to select route kinds: %w", err)
 	}
-	if err := reconciler.SetupWithManager(mgr); err != nil {
-		return fmt.Errorf("unable to set up HTTPRoute controller: %w", err)
+	var routeReconcilers []*controller.RouteReconciler
+	for _, kind := range routeKinds {
+		reconciler := &controller.RouteReconciler{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Log:       ctrl.Log.WithName(strings.ToLower(kind.Kind) + "-controller"),
+			Resolver:  resolver,
+			DNS:       dnsProvider,
+			Upsert:    providerCfg.Upsert,
+			Cluster:   providerCfg.ClusterName,
+			Kind:      kind,
+			Kinds:     routeKinds,
+			Recorder:  mgr.GetEventRecorder("yk-dns-manager"),
+			Health:    providerHealth,
+		}
+		if err := reconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to set up %s controller: %w", kind.Kind, err)
+		}
+		routeReconcilers = append(routeReconcilers, reconciler)
+	}
+
+	if providerCfg.DNSRecords {
+		recordReconciler := &controller.DNSRecordReconciler{
+			Client:  mgr.GetClient(),
+			Log:     ctrl.Log.WithName("dnsrecord-controller"),
+			DNS:     dnsProvider,
+			Cluster: providerCfg.ClusterName,
+			Health:  providerHealth,
+		}
+		if err := recordReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to set up DNSRecord controller: %w", err)
+		}
+	}
+
+	if providerCfg.ResyncInterval > 0 {
+		resyncer := &controller.DriftResyncer{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("drift-resync"),
+			Resolver: resolver,
+			DNS:      dnsProvider,
+			Upsert:   providerCfg.Upsert,
+			Cluster:  providerCfg.ClusterName,
+			Kinds:    routeKinds,
+			Interval: providerCfg.ResyncInterval,
+			Zones:    dnsProvider.Zones,
+		}
+		if err := mgr.Add(resyncer); err != nil {
+			return fmt.Errorf("unable to set up drift resync: %w", err)
+		}
+	} else {
+		counter := &controller.RecordCounter{
+			Log:      ctrl.Log.WithName("record-count"),
+			DNS:      dnsProvider,
+			Cluster:  providerCfg.ClusterName,
+			Interval: managedRecordsInterval,
+			Zones:    dnsProvider.Zones,
+		}
+		if err := mgr.Add(counter); err != nil {
+			return fmt.Errorf("unable to set up managed record count: %w", err)
+		}
+	}
+
+	if providerCfg.GC.Interval > 0 {
+		collector := &controller.OrphanCollector{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("orphan-gc"),
+			Resolver:     resolver,
+			DNS:          dnsProvider,
+			Cluster:      providerCfg.ClusterName,
+			Kinds:        routeKinds,
+			DNSRecords:   providerCfg.DNSRecords,
+			Interval:     providerCfg.GC.Interval,
+			MinAge:       providerCfg.GC.MinAge,
+			MaxDeletions: providerCfg.GC.MaxDeletions,
+			DryRun:       providerCfg.GC.DryRun,
+		}
+		if err := mgr.Add(collector); err != nil {
+			return fmt.Errorf("unable to set up orphan garbage collection: %w", err)
+		}
+	}
+
+	reloader := &controller.ConfigReloader{
+		Log:           ctrl.Log.WithName("config-reload"),
+		DomainMapPath: domainMapPath,
+		ProviderPath:  providerPath,
+		Provider:      providerCfg,
+		Resolver:      resolver,
+		DNS:           dnsProvider,
+		NewProvider:   newProvider,
+		Routes:        routeReconcilers,
+	}
+	if err := mgr.Add(reloader); err != nil {
+		return fmt.Errorf("unable to set up config reload: %w", err)
 	}
 
 	log.Info("starting manager")
@@ -113,3 +237,35 @@ func run() error {
 
 	return nil
 }
+
+// newProvider creates the DNS provider for cfg, wrapped in the configured
+// circuit breaker, retries and rate limit and in provider metrics, and checks
+// that it can reach the DNS server.
+func newProvider(ctx context.Context, cfg *config.ProviderConfig) (dns.Provider, error) {
+	log := ctrl.Log.WithName("dns-" + cfg.Provider)
+	provider, err := dns.NewProvider(cfg.Provider, log, cfg.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create DNS provider: %w", err)
+	}
+	if err := provider.HealthCheck(ctx); err != nil {
+		return nil, fmt.Errorf("DNS provider health check failed: %w", err)
+	}
+	// The breaker counts operations after their retries, every retry waits
+	// for the rate limit, and the metrics see each call that is made.
+	return dns.Chain(provider,
+		dns.WithCircuitBreaker(dns.BreakerPolicy{
+			FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+			OpenDuration:     cfg.CircuitBreaker.OpenDuration,
+		}, log),
+		dns.WithRetry(dns.RetryPolicy{
+			Attempts:       cfg.Retry.Attempts,
+			InitialBackoff: cfg.Retry.InitialBackoff,
+			MaxBackoff:     cfg.Retry.MaxBackoff,
+		}, log),
+		dns.WithRateLimit(dns.RateLimit{
+			WritesPerSecond: cfg.RateLimit.WritesPerSecond,
+			Burst:           cfg.RateLimit.Burst,
+		}),
+		dns.WithMetrics(cfg.Provider),
+	), nil
+}
@@ -2,15 +2,244 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strings"
+	"text/template"
 
 	"go.yaml.in/yaml/v3"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
 )
 
-// DomainMap maps base domains to their load balancer IPs.
+// DomainMap maps base domains to their load balancer IPs or CNAME targets.
 type DomainMap struct {
-	entries map[string]string
+	entries map[string]Entry
+}
+
+// Entry is what a domain maps to: load balancer IPs, published together as
+// round-robin A and AAAA records, or the canonical name hostnames should be a
+// CNAME to, along with how the records are written.
+type Entry struct {
+	IPv4        []string
+	IPv6        []string
+	CNAME       string
+	TTL         int      // record TTL in seconds, 0 leaves it to the provider
+	Description string   // description template, empty for the default description
+	Disabled    bool     // matching hostnames are treated as not matched at all
+	Exclude     []string // hostnames, or "*." patterns, the entry does not apply to
+}
+
+// DescriptionData is what description templates can refer to, e.g.
+// "{{ .Kind }} {{ .Namespace }}/{{ .Name }}".
+type DescriptionData struct {
+	Hostname  string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Record types an entry can produce.
+const (
+	typeA     = "A"
+	typeAAAA  = "AAAA"
+	typeCNAME = "CNAME"
+)
+
+// UnmarshalYAML accepts a single IP address, a list of IPv4 and IPv6
+// addresses, or a mapping, either the short {cname: target} form or the
+// structured form (see unmarshalStructured), e.g.
+//
+//	"*.mydomain.com": "10.0.0.1"
+//	"*.dual.com":     ["10.0.0.1", "fd00::1"]
+//	"*.nodes.com":    ["10.0.0.1", "10.0.0.2", "10.0.0.3"]
+//	"*.apps.com":     {cname: gateway.mydomain.com}
+func (e *Entry) UnmarshalYAML(node *yaml.Node) error {
+	var values []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		values = []string{node.Value}
+	case yaml.SequenceNode:
+		if err := node.Decode(&values); err != nil {
+			return err
+		}
+	case yaml.MappingNode:
+		return e.unmarshalStructured(node)
+	default:
+		return fmt.Errorf("line %d: expected an IP address, a list of IP addresses or a mapping", node.Line)
+	}
+	return e.setIPs(node.Line, values)
+}
+
+// setIPs sorts IP addresses into the entry's address families.
+func (e *Entry) setIPs(line int, values []string) error {
+	for _, value := range values {
+		ip := net.ParseIP(value)
+		switch {
+		case ip == nil:
+			return fmt.Errorf("line %d: invalid IP address %q", line, value)
+		case ip.To4() != nil:
+			if slices.Contains(e.IPv4, ip.String()) {
+				return fmt.Errorf("line %d: duplicate IP address %q", line, value)
+			}
+			e.IPv4 = append(e.IPv4, ip.String())
+		default:
+			if slices.Contains(e.IPv6, ip.String()) {
+				return fmt.Errorf("line %d: duplicate IP address %q", line, value)
+			}
+			e.IPv6 = append(e.IPv6, ip.String())
+		}
+	}
+	if len(e.IPv4) == 0 && len(e.IPv6) == 0 {
+		return fmt.Errorf("line %d: no IP address", line)
+	}
+	return nil
+}
+
+// setCNAME sets the entry's CNAME target.
+func (e *Entry) setCNAME(line int, target string) error {
+	e.CNAME = strings.TrimSuffix(target, ".")
+	switch {
+	case e.CNAME == "":
+		return fmt.Errorf("line %d: missing cname target", line)
+	case net.ParseIP(e.CNAME) != nil:
+		return fmt.Errorf("line %d: cname target %q is an IP address", line, e.CNAME)
+	}
+	return nil
+}
+
+// entrySpec is the structured form of an entry.
+type entrySpec struct {
+	Values      []string `yaml:"values"`
+	Type        string   `yaml:"type"`
+	CNAME       string   `yaml:"cname"`
+	TTL         int      `yaml:"ttl"`
+	Description string   `yaml:"description"`
+	Enabled     *bool    `yaml:"enabled"`
+	Exclude     []string `yaml:"exclude"`
+}
+
+// unmarshalStructured parses the structured form of an entry, e.g.
+//
+//	"*.mydomain.com":
+//	  values: ["10.0.0.1", "10.0.0.2"]
+//	  type: A                  # optional, inferred from the values
+//	  ttl: 60                  # optional, seconds
+//	  description: "{{ .Kind }} {{ .Namespace }}/{{ .Name }}"
+//	  enabled: true            # optional, false stops managing matching hostnames
+//	  exclude: ["legacy.mydomain.com", "*.test.mydomain.com"]
+//
+// {cname: target} is short for {type: CNAME, values: [target]}.
+func (e *Entry) unmarshalStructured(node *yaml.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch key := node.Content[i]; key.Value {
+		case "values", "type", "cname", "ttl", "description", "enabled", "exclude":
+		default:
+			return fmt.Errorf("line %d: unknown field %q", key.Line, key.Value)
+		}
+	}
+	var spec entrySpec
+	if err := node.Decode(&spec); err != nil {
+		return err
+	}
+
+	if spec.CNAME != "" {
+		if len(spec.Values) > 0 || (spec.Type != "" && !strings.EqualFold(spec.Type, typeCNAME)) {
+			return fmt.Errorf("line %d: cname cannot be combined with values or another type", node.Line)
+		}
+		spec.Type, spec.Values = typeCNAME, []string{spec.CNAME}
+	}
+	if err := e.setValues(node.Line, strings.ToUpper(spec.Type), spec.Values); err != nil {
+		return err
+	}
+
+	if spec.TTL < 0 {
+		return fmt.Errorf("line %d: ttl must not be negative", node.Line)
+	}
+	e.TTL = spec.TTL
+	if spec.Description != "" {
+		if _, err := renderDescription(spec.Description, DescriptionData{}); err != nil {
+			return fmt.Errorf("line %d: invalid description template: %w", node.Line, err)
+		}
+	}
+	e.Description = spec.Description
+	e.Disabled = spec.Enabled != nil && !*spec.Enabled
+	for _, pattern := range spec.Exclude {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+		if pattern == "" || pattern == "*." {
+			return fmt.Errorf("line %d: empty exclude pattern", node.Line)
+		}
+		e.Exclude = append(e.Exclude, pattern)
+	}
+	return nil
+}
+
+// setValues sets the entry's values for the given record type, inferring the
+// type from the values when it is empty.
+func (e *Entry) setValues(line int, recordType string, values []string) error {
+	if recordType == "" {
+		recordType = typeA
+		if len(values) == 1 && net.ParseIP(values[0]) == nil {
+			recordType = typeCNAME
+		}
+	}
+	switch recordType {
+	case typeCNAME:
+		if len(values) != 1 {
+			return fmt.Errorf("line %d: a CNAME has exactly one target, got %d", line, len(values))
+		}
+		return e.setCNAME(line, values[0])
+	case typeA, typeAAAA:
+		if err := e.setIPs(line, values); err != nil {
+			return err
+		}
+		if recordType == typeA && len(e.IPv6) > 0 {
+			return fmt.Errorf("line %d: type A only takes IPv4 addresses", line)
+		}
+		if recordType == typeAAAA && len(e.IPv4) > 0 {
+			return fmt.Errorf("line %d: type AAAA only takes IPv6 addresses", line)
+		}
+		return nil
+	default:
+		return fmt.Errorf("line %d: unsupported record type %q", line, recordType)
+	}
+}
+
+// RenderDescription renders the entry's description template for a record,
+// returning an empty string when the entry has none.
+func (e Entry) RenderDescription(data DescriptionData) (string, error) {
+	if e.Description == "" {
+		return "", nil
+	}
+	return renderDescription(e.Description, data)
+}
+
+func renderDescription(text string, data DescriptionData) (string, error) {
+	tmpl, err := template.New("description").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// excludes reports whether hostname matches one of the entry's exclude patterns.
+func (e Entry) excludes(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range e.Exclude {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(hostname, suffix) {
+				return true
+			}
+		} else if hostname == pattern {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadDomainMap reads a YAML file mapping domains to IPs.
@@ -19,43 +248,194 @@ func LoadDomainMap(path string) (*DomainMap, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading domain map file: %w", err)
 	}
+	return ParseDomainMap(data)
+}
 
-	entries := make(map[string]string)
+// DomainMapVersion is the current version of the structured domain map format.
+const DomainMapVersion = 2
+
+// domainMapFile is the structured domain map format:
+//
+//	version: 2
+//	domains:
+//	  "*.mydomain.com":
+//	    values: ["10.0.0.1"]
+//	    ttl: 300
+//
+// Files without a version use the flat format, where every top-level key is a
+// domain.
+type domainMapFile struct {
+	Version int              `yaml:"version"`
+	Domains map[string]Entry `yaml:"domains"`
+}
+
+// ParseDomainMap parses the YAML contents of a domain map file in either the
+// structured or the flat format.
+func ParseDomainMap(data []byte) (*DomainMap, error) {
+	var probe struct {
+		Version int `yaml:"version"`
+	}
+	// A flat map has no version key. A domain literally named "version" maps
+	// to an IP, which doesn't decode as an int.
+	if err := yaml.Unmarshal(data, &probe); err == nil && probe.Version != 0 {
+		if probe.Version != DomainMapVersion {
+			return nil, fmt.Errorf("parsing domain map file: unsupported version %d", probe.Version)
+		}
+		var file domainMapFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parsing domain map file: %w", err)
+		}
+		return newDomainMap(file.Domains)
+	}
+
+	entries := make(map[string]Entry)
 	if err := yaml.Unmarshal(data, &entries); err != nil {
 		return nil, fmt.Errorf("parsing domain map file: %w", err)
 	}
 
-	return &DomainMap{entries: entries}, nil
+	return newDomainMap(entries)
 }
 
-// LookupIP finds the IP for a hostname by matching against domain entries.
+// newDomainMap returns a domain map of entries with their domains and exclude
+// patterns normalized, so they match hostnames in any case and
+// internationalized domains written in Unicode match their punycode form.
+func newDomainMap(entries map[string]Entry) (*DomainMap, error) {
+	normalized := make(map[string]Entry, len(entries))
+	for domain, entry := range entries {
+		key, err := dns.NormalizeHostname(domain)
+		if err != nil {
+			return nil, fmt.Errorf("parsing domain map file: %w", err)
+		}
+		if _, ok := normalized[key]; ok {
+			return nil, fmt.Errorf("parsing domain map file: domain %q is listed more than once", key)
+		}
+		for i, pattern := range entry.Exclude {
+			entry.Exclude[i] = dns.CanonicalHostname(pattern)
+		}
+		normalized[key] = entry
+	}
+	return &DomainMap{entries: normalized}, nil
+}
+
+// Lookup finds the entry for a hostname by matching against domain entries.
 // It walks up the domain labels checking for exact matches and wildcard entries.
 // Exact matches take priority over wildcards. For example, given:
 //
 //	"*.mydomain.com":    "10.0.0.1"
 //	"app2.mydomain.com": "10.0.0.2"
 //
-// "app1.mydomain.com" returns "10.0.0.1" (wildcard match)
-// "app2.mydomain.com" returns "10.0.0.2" (exact match wins)
-func (dm *DomainMap) LookupIP(hostname string) (string, bool) {
-	hostname = strings.TrimSuffix(hostname, ".")
-	// Walk up the domain labels until we find a match
+// "app1.mydomain.com" returns 10.0.0.1 (wildcard match)
+// "app2.mydomain.com" returns 10.0.0.2 (exact match wins)
+//
+// A wildcard hostname such as "*.apps.mydomain.com" stands for every name
+// below apps.mydomain.com. It matches its own wildcard entry, or else the
+// entry any of those names would match, never the exact entry of a single
+// name below apps.mydomain.com.
+//
+// Disabled entries and hostnames excluded by the matching entry are reported
+// as not matched, without falling back to entries of parent domains.
+func (dm *DomainMap) Lookup(hostname string) (Entry, bool) {
+	hostname = dns.CanonicalHostname(hostname)
+	entry, ok := dm.match(hostname)
+	if !ok || entry.Disabled || entry.excludes(hostname) {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// match returns the entry closest to hostname.
+func (dm *DomainMap) match(hostname string) (Entry, bool) {
+	// Walk up the domain labels until we find a match. For a wildcard
+	// hostname the exact match is its own wildcard entry, after which it
+	// matches like a name below its domain without an exact entry.
 	for h := hostname; h != ""; {
 		// Check exact match first
-		if ip, ok := dm.entries[h]; ok {
-			return ip, true
+		if entry, ok := dm.entries[h]; ok {
+			return entry, true
 		}
 		idx := strings.Index(h, ".")
 		if idx < 0 {
 			break
 		}
 		// Check wildcard match at this level
-		if ip, ok := dm.entries["*."+h[idx+1:]]; ok {
-			return ip, true
+		if entry, ok := dm.entries["*."+h[idx+1:]]; ok {
+			return entry, true
 		}
 		h = h[idx+1:]
 	}
-	return "", false
+	return Entry{}, false
+}
+
+// LookupIP is like Lookup but returns a single IP: the first IPv4 address, or
+// the first IPv6 address for IPv6-only entries. CNAME entries match without an
+// IP. Wildcard hostnames match like in Lookup.
+func (dm *DomainMap) LookupIP(hostname string) (string, bool) {
+	entry, ok := dm.Lookup(hostname)
+	if !ok {
+		return "", false
+	}
+	if len(entry.IPv4) > 0 {
+		return entry.IPv4[0], true
+	}
+	if len(entry.IPv6) > 0 {
+		return entry.IPv6[0], true
+	}
+	return "", true
+}
+
+// HasIPv6 reports whether any domain maps to an IPv6 address.
+func (dm *DomainMap) HasIPv6() bool {
+	for _, entry := range dm.entries {
+		if len(entry.IPv6) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCNAME reports whether any domain maps to a CNAME target.
+func (dm *DomainMap) HasCNAME() bool {
+	for _, entry := range dm.entries {
+		if entry.CNAME != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Zones returns the DNS zones inferred from the domain map: the domain covered
+// by every wildcard entry, leaving out those below another one. Exact entries
+// name single hosts, not zones, so they are left out.
+// e.g. "*.mydomain.com", "*.apps.mydomain.com" and "nas.home.lan" → ["mydomain.com"]
+func (dm *DomainMap) Zones() []string {
+	candidates := make(map[string]bool, len(dm.entries))
+	for domain := range dm.entries {
+		if zone, ok := strings.CutPrefix(domain, "*."); ok {
+			candidates[zone] = true
+		}
+	}
+	var zones []string
+	for zone := range candidates {
+		if !hasParent(zone, candidates) {
+			zones = append(zones, zone)
+		}
+	}
+	slices.Sort(zones)
+	return zones
+}
+
+// hasParent reports whether a parent domain of domain is in domains.
+func hasParent(domain string, domains map[string]bool) bool {
+	for {
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return false
+		}
+		if domains[parent] {
+			return true
+		}
+		domain = parent
+	}
 }
 
 // Domains returns all configured base domains.
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -24,10 +25,10 @@ func TestLoadDomainMap(t *testing.T) {
 }
 
 func TestLookupIP(t *testing.T) {
-	dm := &DomainMap{entries: map[string]string{
+	dm := ipv4DomainMap(map[string]string{
 		"my-domain1.com": "10.0.8.100",
 		"my-domain2.it":  "10.0.9.50",
-	}}
+	})
 
 	tests := []struct {
 		hostname string
@@ -57,10 +58,10 @@ func TestLookupIP(t *testing.T) {
 }
 
 func TestLookupIPWildcard(t *testing.T) {
-	dm := &DomainMap{entries: map[string]string{
+	dm := ipv4DomainMap(map[string]string{
 		"*.mydomain.com":    "10.0.0.1",
 		"app2.mydomain.com": "10.0.0.2",
-	}}
+	})
 
 	tests := []struct {
 		hostname string
@@ -89,12 +90,12 @@ func TestLookupIPWildcard(t *testing.T) {
 }
 
 func TestLookupIPWildcardWithBaseDomain(t *testing.T) {
-	dm := &DomainMap{entries: map[string]string{
+	dm := ipv4DomainMap(map[string]string{
 		"*.mydomain.com":       "10.0.0.1",
 		"app2.mydomain.com":    "10.0.0.2",
 		"mydomain.com":         "10.0.0.3",
 		"*.other.mydomain.com": "10.0.0.4",
-	}}
+	})
 
 	tests := []struct {
 		hostname string
@@ -120,3 +121,317 @@ func TestLookupIPWildcardWithBaseDomain(t *testing.T) {
 		})
 	}
 }
+
+func TestLookupIPWildcardQuery(t *testing.T) {
+	dm := ipv4DomainMap(map[string]string{
+		"*.mydomain.com":      "10.0.0.1",
+		"app2.mydomain.com":   "10.0.0.2",
+		"*.apps.mydomain.com": "10.0.0.4",
+		"nodes.mydomain.com":  "10.0.0.5",
+		"app.other.com":       "10.0.0.6",
+	})
+
+	tests := []struct {
+		hostname string
+		wantIP   string
+		wantOK   bool
+	}{
+		{"*.mydomain.com", "10.0.0.1", true},        // its own wildcard entry
+		{"*.apps.mydomain.com", "10.0.0.4", true},   // its own wildcard entry, not *.mydomain.com
+		{"*.app2.mydomain.com", "10.0.0.2", true},   // names below app2 match its entry
+		{"*.web.mydomain.com", "10.0.0.1", true},    // parent wildcard
+		{"*.x.apps.mydomain.com", "10.0.0.4", true}, // nearest parent wildcard
+		{"*.nodes.mydomain.com", "10.0.0.5", true},  // parent domain walk
+		{"*.Apps.MyDomain.com.", "10.0.0.4", true},  // case and trailing dot
+		{"*.other.com", "", false},                  // exact entry below the wildcard's domain
+		{"*.com", "", false},                        // no entry
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			ip, ok := dm.LookupIP(tt.hostname)
+			if ok != tt.wantOK {
+				t.Errorf("LookupIP(%q): got ok=%v, want %v", tt.hostname, ok, tt.wantOK)
+			}
+			if ip != tt.wantIP {
+				t.Errorf("LookupIP(%q): got ip=%q, want %q", tt.hostname, ip, tt.wantIP)
+			}
+		})
+	}
+
+	excluding := &DomainMap{entries: map[string]Entry{
+		"*.mydomain.com": {IPv4: []string{"10.0.0.1"}, Exclude: []string{"*.test.mydomain.com", "legacy.mydomain.com"}},
+	}}
+	if _, ok := excluding.LookupIP("*.test.mydomain.com"); ok {
+		t.Error("expected a wildcard covered by an exclude pattern not to match")
+	}
+	if _, ok := excluding.LookupIP("*.legacy.mydomain.com"); !ok {
+		t.Error("expected a wildcard below an excluded name to match")
+	}
+}
+
+// ipv4DomainMap builds a domain map from IPv4-only entries.
+func ipv4DomainMap(entries map[string]string) *DomainMap {
+	dm := &DomainMap{entries: make(map[string]Entry, len(entries))}
+	for domain, ip := range entries {
+		dm.entries[domain] = Entry{IPv4: []string{ip}}
+	}
+	return dm
+}
+
+func TestLoadDomainMap_DualStack(t *testing.T) {
+	content := `
+v4.com: 10.0.0.1
+v6.com: "fd00::1"
+dual.com: ["10.0.0.2", "fd00:0::2"]
+`
+	path := filepath.Join(t.TempDir(), "domain-map.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dm, err := LoadDomainMap(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		hostname string
+		want     Entry
+	}{
+		{"app.v4.com", Entry{IPv4: []string{"10.0.0.1"}}},
+		{"app.v6.com", Entry{IPv6: []string{"fd00::1"}}},
+		{"app.dual.com", Entry{IPv4: []string{"10.0.0.2"}, IPv6: []string{"fd00::2"}}},
+	}
+	for _, tt := range tests {
+		got, ok := dm.Lookup(tt.hostname)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q) = %+v, %v, want %+v", tt.hostname, got, ok, tt.want)
+		}
+	}
+	if ip, _ := dm.LookupIP("app.v6.com"); ip != "fd00::1" {
+		t.Errorf("expected LookupIP to fall back to the IPv6 address, got %q", ip)
+	}
+	if !dm.HasIPv6() {
+		t.Error("expected HasIPv6 to be true")
+	}
+}
+
+func TestLoadDomainMap_MultipleIPs(t *testing.T) {
+	content := "nodes.com: [10.0.0.1, 10.0.0.2, \"fd00::1\", 10.0.0.3]\n"
+	path := filepath.Join(t.TempDir(), "domain-map.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dm, err := LoadDomainMap(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Entry{IPv4: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, IPv6: []string{"fd00::1"}}
+	if got, _ := dm.Lookup("app.nodes.com"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup = %+v, want %+v", got, want)
+	}
+	if ip, _ := dm.LookupIP("app.nodes.com"); ip != "10.0.0.1" {
+		t.Errorf("expected LookupIP to return the first IPv4 address, got %q", ip)
+	}
+}
+
+func TestLoadDomainMap_InvalidAddresses(t *testing.T) {
+	tests := map[string]string{
+		"not an IP":      "a.com: nas.local\n",
+		"duplicate IPv4": "a.com: [10.0.0.1, 10.0.0.1]\n",
+		"duplicate IPv6": "a.com: [\"fd00::1\", \"fd00:0::1\"]\n",
+		"empty list":     "a.com: []\n",
+		"unknown field":  "a.com: {ip: 10.0.0.1}\n",
+		"empty cname":    "a.com: {cname: \"\"}\n",
+		"cname to an IP": "a.com: {cname: 10.0.0.1}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "domain-map.yaml")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadDomainMap(path); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestLoadDomainMap_CNAME(t *testing.T) {
+	content := "apps.com: {cname: gateway.apps.com.}\ngateway.apps.com: 10.0.0.1\n"
+	path := filepath.Join(t.TempDir(), "domain-map.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dm, err := LoadDomainMap(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := dm.Lookup("app.apps.com"); !reflect.DeepEqual(got, Entry{CNAME: "gateway.apps.com"}) {
+		t.Errorf("expected CNAME entry without trailing dot, got %+v", got)
+	}
+	if got, _ := dm.Lookup("gateway.apps.com"); !reflect.DeepEqual(got, Entry{IPv4: []string{"10.0.0.1"}}) {
+		t.Errorf("expected exact IP entry for the target, got %+v", got)
+	}
+	if !dm.HasCNAME() {
+		t.Error("expected HasCNAME to be true")
+	}
+}
+
+func TestLoadDomainMap_Structured(t *testing.T) {
+	content := `
+version: 2
+domains:
+  "*.volatile.com":
+    values: ["10.0.0.1", "10.0.0.2"]
+    ttl: 30
+    description: "{{ .Kind }} {{ .Namespace }}/{{ .Name }}"
+    exclude: ["legacy.volatile.com", "*.test.volatile.com."]
+  "*.stable.com":
+    type: AAAA
+    values: ["fd00::1"]
+    ttl: 86400
+  "*.apps.com":
+    values: [gateway.stable.com]
+  "off.volatile.com":
+    values: ["10.0.0.9"]
+    enabled: false
+  "flat.com": 10.0.0.5
+`
+	path := filepath.Join(t.TempDir(), "domain-map.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dm, err := LoadDomainMap(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		hostname string
+		want     Entry
+		wantOK   bool
+	}{
+		{"app.volatile.com", Entry{
+			IPv4:        []string{"10.0.0.1", "10.0.0.2"},
+			TTL:         30,
+			Description: "{{ .Kind }} {{ .Namespace }}/{{ .Name }}",
+			Exclude:     []string{"legacy.volatile.com", "*.test.volatile.com"},
+		}, true},
+		{"legacy.volatile.com", Entry{}, false},
+		{"a.b.test.volatile.com", Entry{}, false},
+		{"off.volatile.com", Entry{}, false},
+		{"app.stable.com", Entry{IPv6: []string{"fd00::1"}, TTL: 86400}, true},
+		{"app.apps.com", Entry{CNAME: "gateway.stable.com"}, true},
+		{"app.flat.com", Entry{IPv4: []string{"10.0.0.5"}}, true},
+	}
+	for _, tt := range tests {
+		got, ok := dm.Lookup(tt.hostname)
+		if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q) = %+v, %v, want %+v, %v", tt.hostname, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	entry, _ := dm.Lookup("app.volatile.com")
+	got, err := entry.RenderDescription(DescriptionData{Kind: "HTTPRoute", Namespace: "default", Name: "web"})
+	if err != nil || got != "HTTPRoute default/web" {
+		t.Errorf("RenderDescription = %q, %v", got, err)
+	}
+}
+
+func TestLoadDomainMap_StructuredInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unsupported version": "version: 3\ndomains: {}\n",
+		"unknown field":       "version: 2\ndomains:\n  a.com: {values: [10.0.0.1], weight: 2}\n",
+		"A with IPv6":         "version: 2\ndomains:\n  a.com: {type: A, values: [\"fd00::1\"]}\n",
+		"AAAA with IPv4":      "version: 2\ndomains:\n  a.com: {type: AAAA, values: [10.0.0.1]}\n",
+		"two CNAME targets":   "version: 2\ndomains:\n  a.com: {type: CNAME, values: [x.com, y.com]}\n",
+		"cname with values":   "version: 2\ndomains:\n  a.com: {cname: x.com, values: [10.0.0.1]}\n",
+		"unsupported type":    "version: 2\ndomains:\n  a.com: {type: MX, values: [mail.a.com]}\n",
+		"negative ttl":        "version: 2\ndomains:\n  a.com: {values: [10.0.0.1], ttl: -1}\n",
+		"bad template":        "version: 2\ndomains:\n  a.com: {values: [10.0.0.1], description: \"{{ .Owner }}\"}\n",
+		"empty exclude":       "version: 2\ndomains:\n  a.com: {values: [10.0.0.1], exclude: [\"\"]}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "domain-map.yaml")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadDomainMap(path); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestParseDomainMap_Normalized(t *testing.T) {
+	dm, err := ParseDomainMap([]byte(`
+"*.Bücher.example": 10.0.0.1
+My-Domain1.com.: 10.0.8.100
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, hostname := range []string{"shop.xn--bcher-kva.example", "shop.bücher.example", "APP.my-domain1.com"} {
+		if _, ok := dm.LookupIP(hostname); !ok {
+			t.Errorf("expected %q to match", hostname)
+		}
+	}
+
+	if _, err := ParseDomainMap([]byte("example.com: 10.0.0.1\nExample.com: 10.0.0.2\n")); err == nil {
+		t.Error("expected error for a domain listed twice")
+	}
+}
+
+func TestDomainMapZones(t *testing.T) {
+	dm, err := ParseDomainMap([]byte(`
+"*.mydomain.com": 10.0.0.1
+app2.mydomain.com: 10.0.0.2
+"*.apps.mydomain.com": 10.0.0.3
+my-domain1.com: 10.0.8.100
+"*.lab.my-domain2.it": 10.0.9.50
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"lab.my-domain2.it", "mydomain.com"}
+	if got := dm.Zones(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected zones %v, got %v", want, got)
+	}
+
+	cfg := &ProviderConfig{}
+	if got := cfg.ZoneNames(dm); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected inferred zones %v, got %v", want, got)
+	}
+	if got := cfg.ZoneNames(nil); got != nil {
+		t.Errorf("expected no zones without a domain map, got %v", got)
+	}
+	cfg.Zones = []string{"my-domain2.it"}
+	if got := cfg.ZoneNames(dm); !reflect.DeepEqual(got, cfg.Zones) {
+		t.Errorf("expected configured zones to win, got %v", got)
+	}
+}
+
+func TestDomainMapZones_LoneExactEntry(t *testing.T) {
+	dm, err := ParseDomainMap([]byte(`
+nas.home.lan: 10.0.0.5
+printer.office.lan: 10.0.0.6
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := dm.Zones(); len(got) != 0 {
+		t.Errorf("expected no zones inferred from exact entries, got %v", got)
+	}
+}
@@ -3,27 +3,109 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"go.yaml.in/yaml/v3"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// DefaultClusterName is used in record owner IDs when cluster_name is not set.
+const DefaultClusterName = "default"
+
+// Record value sources.
+const (
+	ValueSourceDomainMap = "domain-map" // values come from the domain map
+	ValueSourceGateway   = "gateway"    // values come from parent Gateway status addresses
+)
+
+// Domain map modes for the gateway value source.
+const (
+	DomainMapOverride  = "override"  // domain map values win over Gateway addresses
+	DomainMapAllowlist = "allowlist" // only hostnames in the domain map are managed
+)
+
+// Resource kinds that can be used as hostname sources.
+const (
+	RouteKindHTTPRoute = "HTTPRoute"
+	RouteKindGRPCRoute = "GRPCRoute"
+	RouteKindTLSRoute  = "TLSRoute"
+	RouteKindIngress   = "Ingress"
+	RouteKindService   = "Service"
 )
 
 // ProviderConfig holds the DNS provider type, app-level options, and
 // provider-specific connection settings.
 type ProviderConfig struct {
-	Provider string            `yaml:"provider"`
-	Upsert   bool              `yaml:"upsert"`
-	Settings map[string]string `yaml:"settings"`
+	Provider       string            `yaml:"provider"`
+	Upsert         bool              `yaml:"upsert"`
+	Retry          RetryConfig       `yaml:"retry"`           // retries of provider calls failing with a retryable error
+	RateLimit      RateLimitConfig   `yaml:"rate_limit"`      // rate limit of provider write calls
+	CircuitBreaker BreakerConfig     `yaml:"circuit_breaker"` // stops calling a failing DNS server for a while
+	ResyncInterval time.Duration     `yaml:"resync_interval"` // 0 disables periodic drift correction
+	ClusterName    string            `yaml:"cluster_name"`    // identifies this cluster in record owner IDs
+	ValueSource    string            `yaml:"value_source"`    // where record values come from, see ValueSource*
+	DomainMapMode  string            `yaml:"domain_map_mode"` // gateway source only, see DomainMap*
+	RouteKinds     []string          `yaml:"route_kinds"`     // route kinds to watch, see RouteKind*
+	IngressClass   string            `yaml:"ingress_class"`   // only manage Ingresses of this class, empty for all
+	DNSRecords     bool              `yaml:"dns_records"`     // reconcile DNSRecord resources, requires the CRD
+	Zones          []string          `yaml:"zones"`           // DNS zones hostnames are split on, inferred from the domain map when empty
+	GC             GCConfig          `yaml:"garbage_collection"`
+	Settings       map[string]string `yaml:"settings"`
+}
+
+// RetryConfig controls retries of provider calls that fail with a retryable
+// error, such as a timeout or an HTTP 502.
+type RetryConfig struct {
+	Attempts       int           `yaml:"attempts"`        // calls per operation including the first, 0 or 1 disables retries
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // delay before the first retry, doubled for each further one
+	MaxBackoff     time.Duration `yaml:"max_backoff"`     // upper bound of the delay
+}
+
+// RateLimitConfig limits the rate of provider calls that write records.
+type RateLimitConfig struct {
+	WritesPerSecond float64 `yaml:"writes_per_second"` // 0 disables the limit
+	Burst           int     `yaml:"burst"`             // writes allowed at once, defaults to writes_per_second rounded up
+}
+
+// BreakerConfig controls the circuit breaker, which stops calling the DNS
+// server after consecutive failed calls.
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // consecutive failed calls that open the circuit, 0 disables the breaker
+	OpenDuration     time.Duration `yaml:"open_duration"`     // how long the circuit stays open before a trial call
+}
+
+// Defaults for the provider middleware options left out of an enabled block.
+const (
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultBreakerOpenDuration = 30 * time.Second
+)
+
+// GCConfig controls garbage collection of orphaned records.
+type GCConfig struct {
+	Interval     time.Duration `yaml:"interval"`      // 0 disables garbage collection
+	MinAge       time.Duration `yaml:"min_age"`       // how long a record must stay orphaned before deletion
+	MaxDeletions int           `yaml:"max_deletions"` // per-run deletion cap, 0 means no limit
+	DryRun       bool          `yaml:"dry_run"`       // only report orphans, never delete
 }
 
 // LoadProviderConfig reads the DNS provider configuration from the path
 // specified by the DNS_PROVIDER_PATH environment variable, defaulting to
 // "configs/dns-provider.yaml".
 func LoadProviderConfig() (*ProviderConfig, error) {
-	path := os.Getenv("DNS_PROVIDER_PATH")
-	if path == "" {
-		path = "configs/dns-provider.yaml"
+	return LoadProviderConfigFromPath(ProviderConfigPath())
+}
+
+// ProviderConfigPath returns the provider config path from the
+// DNS_PROVIDER_PATH environment variable, defaulting to
+// "configs/dns-provider.yaml".
+func ProviderConfigPath() string {
+	if path := os.Getenv("DNS_PROVIDER_PATH"); path != "" {
+		return path
 	}
-	return LoadProviderConfigFromPath(path)
+	return "configs/dns-provider.yaml"
 }
 
 // LoadProviderConfigFromPath reads the DNS provider configuration from the
@@ -33,7 +115,12 @@ func LoadProviderConfigFromPath(path string) (*ProviderConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading provider config file: %w", err)
 	}
+	return ParseProviderConfig(data)
+}
 
+// ParseProviderConfig parses and validates the YAML contents of a provider
+// config file.
+func ParseProviderConfig(data []byte) (*ProviderConfig, error) {
 	var cfg ProviderConfig
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing provider config file: %w", err)
@@ -43,6 +130,76 @@ func LoadProviderConfigFromPath(path string) (*ProviderConfig, error) {
 		return nil, fmt.Errorf("provider config: missing required field 'provider'")
 	}
 
+	if cfg.ClusterName == "" {
+		cfg.ClusterName = DefaultClusterName
+	}
+	if strings.Contains(cfg.ClusterName, "/") {
+		return nil, fmt.Errorf("provider config: cluster_name must not contain '/'")
+	}
+
+	switch cfg.ValueSource {
+	case "":
+		cfg.ValueSource = ValueSourceDomainMap
+	case ValueSourceDomainMap, ValueSourceGateway:
+	default:
+		return nil, fmt.Errorf("provider config: unsupported value_source %q", cfg.ValueSource)
+	}
+	switch cfg.DomainMapMode {
+	case "":
+		cfg.DomainMapMode = DomainMapOverride
+	case DomainMapOverride, DomainMapAllowlist:
+	default:
+		return nil, fmt.Errorf("provider config: unsupported domain_map_mode %q", cfg.DomainMapMode)
+	}
+
+	if len(cfg.RouteKinds) == 0 {
+		cfg.RouteKinds = []string{RouteKindHTTPRoute}
+	}
+	for _, kind := range cfg.RouteKinds {
+		switch kind {
+		case RouteKindHTTPRoute, RouteKindGRPCRoute, RouteKindTLSRoute, RouteKindIngress, RouteKindService:
+		case "TCPRoute", "UDPRoute":
+			return nil, fmt.Errorf("provider config: route kind %q has no hostnames and cannot be a DNS source", kind)
+		default:
+			return nil, fmt.Errorf("provider config: unsupported route kind %q", kind)
+		}
+	}
+
+	if _, err := dns.NewZones(cfg.Zones...); err != nil {
+		return nil, fmt.Errorf("provider config: %w", err)
+	}
+
+	if cfg.ResyncInterval < 0 {
+		return nil, fmt.Errorf("provider config: resync_interval must not be negative")
+	}
+	if cfg.GC.Interval < 0 || cfg.GC.MinAge < 0 || cfg.GC.MaxDeletions < 0 {
+		return nil, fmt.Errorf("provider config: garbage_collection values must not be negative")
+	}
+
+	if cfg.Retry.Attempts < 0 || cfg.Retry.InitialBackoff < 0 || cfg.Retry.MaxBackoff < 0 {
+		return nil, fmt.Errorf("provider config: retry values must not be negative")
+	}
+	if cfg.Retry.Attempts > 1 {
+		if cfg.Retry.InitialBackoff == 0 {
+			cfg.Retry.InitialBackoff = defaultRetryInitialBackoff
+		}
+		if cfg.Retry.MaxBackoff == 0 {
+			cfg.Retry.MaxBackoff = max(defaultRetryMaxBackoff, cfg.Retry.InitialBackoff)
+		}
+		if cfg.Retry.MaxBackoff < cfg.Retry.InitialBackoff {
+			return nil, fmt.Errorf("provider config: retry max_backoff must not be below initial_backoff")
+		}
+	}
+	if cfg.RateLimit.WritesPerSecond < 0 || cfg.RateLimit.Burst < 0 {
+		return nil, fmt.Errorf("provider config: rate_limit values must not be negative")
+	}
+	if cfg.CircuitBreaker.FailureThreshold < 0 || cfg.CircuitBreaker.OpenDuration < 0 {
+		return nil, fmt.Errorf("provider config: circuit_breaker values must not be negative")
+	}
+	if cfg.CircuitBreaker.FailureThreshold > 0 && cfg.CircuitBreaker.OpenDuration == 0 {
+		cfg.CircuitBreaker.OpenDuration = defaultBreakerOpenDuration
+	}
+
 	// Expand ${ENV_VAR} references in setting values.
 	for k, v := range cfg.Settings {
 		cfg.Settings[k] = os.ExpandEnv(v)
@@ -50,3 +207,15 @@ func LoadProviderConfigFromPath(path string) (*ProviderConfig, error) {
 
 	return &cfg, nil
 }
+
+// ZoneNames returns the DNS zones hostnames are split on: the configured zones,
+// or else the zones inferred from the domain map, which may be nil.
+func (cfg *ProviderConfig) ZoneNames(dm *DomainMap) []string {
+	if len(cfg.Zones) > 0 {
+		return cfg.Zones
+	}
+	if dm == nil {
+		return nil
+	}
+	return dm.Zones()
+}
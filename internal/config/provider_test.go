@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadProviderConfig(t *testing.T) {
@@ -36,6 +37,15 @@ settings:
 	if cfg.Settings["default_ttl"] != "300" {
 		t.Errorf("expected default_ttl '300', got %q", cfg.Settings["default_ttl"])
 	}
+	if cfg.ValueSource != ValueSourceDomainMap {
+		t.Errorf("expected value source %q, got %q", ValueSourceDomainMap, cfg.ValueSource)
+	}
+	if cfg.ClusterName != DefaultClusterName {
+		t.Errorf("expected cluster name %q, got %q", DefaultClusterName, cfg.ClusterName)
+	}
+	if len(cfg.RouteKinds) != 1 || cfg.RouteKinds[0] != RouteKindHTTPRoute {
+		t.Errorf("expected route kinds [%s], got %v", RouteKindHTTPRoute, cfg.RouteKinds)
+	}
 }
 
 func TestLoadProviderConfig_UpsertTrue(t *testing.T) {
@@ -83,6 +93,156 @@ settings:
 	}
 }
 
+func TestLoadProviderConfig_ResyncInterval(t *testing.T) {
+	content := `provider: opnsense
+resync_interval: 5m
+settings:
+  base_url: "https://opnsense.local/api"
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadProviderConfigFromPath(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.ResyncInterval != 5*time.Minute {
+		t.Errorf("expected resync interval 5m, got %s", cfg.ResyncInterval)
+	}
+}
+
+func TestLoadProviderConfig_GarbageCollection(t *testing.T) {
+	content := `provider: opnsense
+garbage_collection:
+  interval: 1h
+  min_age: 30m
+  max_deletions: 5
+  dry_run: true
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadProviderConfigFromPath(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.GC.Interval != time.Hour {
+		t.Errorf("expected interval 1h, got %s", cfg.GC.Interval)
+	}
+	if cfg.GC.MinAge != 30*time.Minute {
+		t.Errorf("expected min_age 30m, got %s", cfg.GC.MinAge)
+	}
+	if cfg.GC.MaxDeletions != 5 {
+		t.Errorf("expected max_deletions 5, got %d", cfg.GC.MaxDeletions)
+	}
+	if !cfg.GC.DryRun {
+		t.Error("expected dry_run to be true")
+	}
+}
+
+func TestLoadProviderConfig_GatewayValueSource(t *testing.T) {
+	content := `provider: opnsense
+value_source: gateway
+domain_map_mode: allowlist
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadProviderConfigFromPath(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.ValueSource != ValueSourceGateway {
+		t.Errorf("expected value source %q, got %q", ValueSourceGateway, cfg.ValueSource)
+	}
+	if cfg.DomainMapMode != DomainMapAllowlist {
+		t.Errorf("expected domain map mode %q, got %q", DomainMapAllowlist, cfg.DomainMapMode)
+	}
+}
+
+func TestLoadProviderConfig_RouteKinds(t *testing.T) {
+	content := `provider: opnsense
+route_kinds: [HTTPRoute, GRPCRoute, TLSRoute, Ingress]
+ingress_class: nginx
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadProviderConfigFromPath(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{RouteKindHTTPRoute, RouteKindGRPCRoute, RouteKindTLSRoute, RouteKindIngress}
+	if len(cfg.RouteKinds) != len(want) {
+		t.Fatalf("expected route kinds %v, got %v", want, cfg.RouteKinds)
+	}
+	for i := range want {
+		if cfg.RouteKinds[i] != want[i] {
+			t.Errorf("expected route kinds %v, got %v", want, cfg.RouteKinds)
+		}
+	}
+	if cfg.IngressClass != "nginx" {
+		t.Errorf("expected ingress class 'nginx', got %q", cfg.IngressClass)
+	}
+}
+
+func TestLoadProviderConfig_RouteKindWithoutHostnames(t *testing.T) {
+	content := `provider: opnsense
+route_kinds: [HTTPRoute, TCPRoute]
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadProviderConfigFromPath(path)
+	if err == nil {
+		t.Fatal("expected error for TCPRoute, which has no hostnames, got nil")
+	}
+}
+
+func TestLoadProviderConfig_InvalidValueSource(t *testing.T) {
+	content := `provider: opnsense
+value_source: dhcp
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadProviderConfigFromPath(path)
+	if err == nil {
+		t.Fatal("expected error for unsupported value_source, got nil")
+	}
+}
+
+func TestLoadProviderConfig_InvalidClusterName(t *testing.T) {
+	content := `provider: opnsense
+cluster_name: "prod/eu"
+`
+	path := filepath.Join(t.TempDir(), "dns-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadProviderConfigFromPath(path)
+	if err == nil {
+		t.Fatal("expected error for cluster_name containing '/', got nil")
+	}
+}
+
 func TestLoadProviderConfig_MissingProvider(t *testing.T) {
 	content := `settings:
   base_url: "https://opnsense.local/api"
@@ -163,3 +323,63 @@ func TestLoadProviderConfig_MissingFile(t *testing.T) {
 		t.Fatal("expected error for missing file, got nil")
 	}
 }
+
+func TestLoadProviderConfig_Middleware(t *testing.T) {
+	content := `provider: opnsense
+upsert: true
+retry:
+  attempts: 4
+  initial_backoff: 200ms
+rate_limit:
+  writes_per_second: 2.5
+circuit_breaker:
+  failure_threshold: 5
+`
+	cfg, err := ParseProviderConfig([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := RetryConfig{Attempts: 4, InitialBackoff: 200 * time.Millisecond, MaxBackoff: defaultRetryMaxBackoff}
+	if cfg.Retry != want {
+		t.Errorf("expected retry %+v, got %+v", want, cfg.Retry)
+	}
+	if cfg.RateLimit != (RateLimitConfig{WritesPerSecond: 2.5}) {
+		t.Errorf("expected rate_limit of 2.5 writes per second, got %+v", cfg.RateLimit)
+	}
+	if cfg.CircuitBreaker != (BreakerConfig{FailureThreshold: 5, OpenDuration: defaultBreakerOpenDuration}) {
+		t.Errorf("expected circuit_breaker with the default open_duration, got %+v", cfg.CircuitBreaker)
+	}
+
+	cfg, err = ParseProviderConfig([]byte("provider: opnsense\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Retry != (RetryConfig{}) || cfg.RateLimit != (RateLimitConfig{}) || cfg.CircuitBreaker != (BreakerConfig{}) {
+		t.Errorf("expected the middlewares to be disabled when omitted, got %+v %+v %+v", cfg.Retry, cfg.RateLimit, cfg.CircuitBreaker)
+	}
+
+	for _, invalid := range []string{
+		"retry: {attempts: -1}",
+		"retry: {attempts: 3, initial_backoff: 5s, max_backoff: 1s}",
+		"rate_limit: {writes_per_second: -1}",
+		"circuit_breaker: {failure_threshold: 3, open_duration: -1s}",
+	} {
+		if _, err := ParseProviderConfig([]byte("provider: opnsense\n" + invalid + "\n")); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestLoadProviderConfig_Zones(t *testing.T) {
+	cfg, err := ParseProviderConfig([]byte("provider: opnsense\nzones: [example.com, lan]\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Zones) != 2 || cfg.Zones[0] != "example.com" || cfg.Zones[1] != "lan" {
+		t.Errorf("expected zones [example.com lan], got %v", cfg.Zones)
+	}
+
+	if _, err := ParseProviderConfig([]byte("provider: opnsense\nzones: [\"*.example.com\"]\n")); err == nil {
+		t.Error("expected error for a wildcard zone")
+	}
+}
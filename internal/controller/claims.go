@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Several routes may list the same hostname, e.g. a canary and a stable route.
+// The record is owned by the route that created it; the others share it. When
+// the owner gives the hostname up while another route still claims it, the
+// record is handed over to that route instead of being deleted.
+
+// hostnameIndex is the field index on route caches that maps a hostname to the
+// objects listing it.
+const hostnameIndex = "dns.yk/hostname"
+
+// indexHostnames returns the hostname index values of obj: its canonical
+// hostnames.
+func (k RouteKind) indexHostnames(obj client.Object) []string {
+	hostnames := k.hostnames(obj)
+	values := make([]string, 0, len(hostnames))
+	for _, h := range hostnames {
+		values = append(values, claimKey(h))
+	}
+	return values
+}
+
+// claimKey normalises a hostname for the hostname index.
+func claimKey(hostname string) string {
+	return dns.CanonicalHostname(hostname)
+}
+
+// IndexHostnames registers the hostname index for objects of kind.
+func IndexHostnames(ctx context.Context, indexer client.FieldIndexer, kind RouteKind) error {
+	if err := indexer.IndexField(ctx, kind.newObject(), hostnameIndex, kind.indexHostnames); err != nil {
+		return fmt.Errorf("indexing %s hostnames: %w", kind.Kind, err)
+	}
+	return nil
+}
+
+// claimant is a route claiming a hostname.
+type claimant struct {
+	Kind  RouteKind
+	Route client.Object
+	Owner string // owner ID the route writes records with
+}
+
+// claimKinds returns the kinds searched for routes claiming a hostname.
+func (r *RouteReconciler) claimKinds() []RouteKind {
+	if len(r.Kinds) == 0 {
+		return []RouteKind{r.kind()}
+	}
+	return r.Kinds
+}
+
+// claimants returns the routes other than owner that claim hostname, sorted by
+// owner ID. Routes being deleted, routes that are not selected and routes of
+// kinds for which the hostname is not managed don't claim it.
+func (r *RouteReconciler) claimants(ctx context.Context, hostname, owner string) ([]claimant, error) {
+	var claims []claimant
+	for _, kind := range r.claimKinds() {
+		if !r.Resolver.Manages(kind, hostname) {
+			continue
+		}
+		list := kind.newList()
+		if err := r.List(ctx, list, client.MatchingFields{hostnameIndex: claimKey(hostname)}); err != nil {
+			return nil, fmt.Errorf("listing %ss claiming %s: %w", kind.Kind, hostname, err)
+		}
+		for _, obj := range kind.items(list) {
+			id := dns.OwnerID(r.Cluster, kind.Kind, obj.GetNamespace(), obj.GetName())
+			if id == owner || !obj.GetDeletionTimestamp().IsZero() || !kind.selected(obj) {
+				continue
+			}
+			claims = append(claims, claimant{Kind: kind, Route: obj, Owner: id})
+		}
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].Owner < claims[j].Owner })
+	return claims, nil
+}
+
+// ownedBy reports whether a record tagged with recordOwner belongs to owner,
+// also when it was tagged with owner's legacy owner ID.
+func ownedBy(recordOwner, owner string) bool {
+	return recordOwner == owner || recordOwner != "" && recordOwner == dns.LegacyOwnerID(owner)
+}
+
+// existingRecord returns the record the provider holds for record's hostname
+// and type, or nil unless it holds exactly one. When that record is owned by
+// another route still claiming the hostname, the route is returned as well.
+func (r *RouteReconciler) existingRecord(ctx context.Context, record dns.Record, owner string) (*dns.Record, *claimant, error) {
+	existing, err := r.DNS.List(ctx, dns.ListFilter{Hostname: record.Hostname, Type: record.Type})
+	if err != nil || len(existing) != 1 {
+		return nil, nil, err
+	}
+	rec := existing[0]
+	if rec.Owner() == "" || ownedBy(rec.Owner(), owner) {
+		return &rec, nil, nil
+	}
+	claims, err := r.claimants(ctx, record.Hostname, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range claims {
+		if ownedBy(rec.Owner(), c.Owner) {
+			return &rec, &c, nil
+		}
+	}
+	return &rec, nil, nil
+}
+
+// conflictMessage describes a record a route of kind wants that another route
+// already holds with different values.
+func conflictMessage(kind RouteKind, want, existing dns.Record, other claimant) string {
+	return fmt.Sprintf("%s %s is also claimed by %s %s/%s with values %s, this %s wants %s",
+		want.Type, want.Hostname, other.Kind.Kind, other.Route.GetNamespace(), other.Route.GetName(),
+		strings.Join(existing.Values, ", "), kind.Kind, strings.Join(want.Values, ", "))
+}
+
+// handOver is called when the route owning hostname gives it up. If other
+// routes still claim the hostname, it returns the owner ID of the first one and
+// the records of hostname held by owner re-tagged with it, so they are taken
+// over instead of deleted. It returns an empty owner when no other route
+// claims the hostname.
+func (r *RouteReconciler) handOver(ctx context.Context, kind RouteKind, hostname, owner string) (string, []dns.Record, error) {
+	claims, err := r.claimants(ctx, hostname, owner)
+	if err != nil || len(claims) == 0 {
+		return "", nil, err
+	}
+	next := claims[0].Owner
+	r.Log.Info("hostname still claimed by another route, keeping its DNS records",
+		"hostname", hostname, "claimedBy", next, "claimants", len(claims))
+
+	var records []dns.Record
+	for _, recordType := range r.Resolver.recordTypes(kind) {
+		existing, err := r.DNS.List(ctx, dns.ListFilter{Hostname: hostname, Type: recordType})
+		if err != nil {
+			return "", nil, fmt.Errorf("listing DNS records for %s: %w", hostname, err)
+		}
+		for _, rec := range existing {
+			if !ownedBy(rec.Owner(), owner) {
+				continue
+			}
+			records = append(records, dns.Record{
+				Hostname: hostname,
+				Type:     rec.Type,
+				Values:   rec.Values,
+				TTL:      rec.TTL,
+				Meta: map[string]string{
+					"description":         rec.Meta["description"],
+					dns.MetaOwner:         next,
+					dns.MetaPreviousOwner: rec.Owner(),
+				},
+			})
+		}
+	}
+	return next, records, nil
+}
+
+// withHandovers adds the records handed over to other routes to the updates of
+// changes, so they are applied in the same batch.
+func withHandovers(changes dns.ChangeSet, handovers []dns.Record) dns.ChangeSet {
+	if len(handovers) > 0 {
+		changes.Updates = slices.Concat(changes.Updates, handovers)
+	}
+	return changes
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// newSharedRoute returns a reconciled HTTPRoute listing hostnames.
+func newSharedRoute(name string, hostnames ...gatewayv1.Hostname) *gatewayv1.HTTPRoute {
+	return &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Finalizers: []string{finalizerName}},
+		Spec:       gatewayv1.HTTPRouteSpec{Hostnames: hostnames},
+	}
+}
+
+func newClaimsReconciler(t *testing.T, mock *mockDNSProvider, recorder events.EventRecorder, objs ...client.Object) (*RouteReconciler, client.Client) {
+	t.Helper()
+	fakeClient := newGatewayClient(t, objs...)
+	return &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Upsert:    true,
+		Cluster:   "prod",
+		Recorder:  recorder,
+	}, fakeClient
+}
+
+func TestRouteReconciler_DeletionHandsOverSharedHostname(t *testing.T) {
+	now := metav1.Now()
+	canary := newSharedRoute("canary", "app.my-domain1.com", "canary.my-domain1.com")
+	canary.DeletionTimestamp = &now
+	stable := newSharedRoute("stable", "app.my-domain1.com")
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}, TTL: 300,
+				Meta: map[string]string{"description": "web", dns.MetaOwner: "prod/HTTPRoute/default/canary"}},
+			{Hostname: "canary.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"},
+				Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/canary"}},
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, _ := newClaimsReconciler(t, mock, recorder, canary, stable)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "canary", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(mock.deletedHosts, []string{"canary.my-domain1.com"}) {
+		t.Errorf("expected only the unshared hostname to be deleted, got %v", mock.deletedHosts)
+	}
+	if len(mock.updatedRecords) != 1 {
+		t.Fatalf("expected the shared record to be handed over, got %v", mock.updatedRecords)
+	}
+	rec := mock.updatedRecords[0]
+	if rec.Hostname != "app.my-domain1.com" || rec.Owner() != "prod/HTTPRoute/default/stable" || rec.Meta[dns.MetaPreviousOwner] != "prod/HTTPRoute/default/canary" {
+		t.Errorf("expected app.my-domain1.com handed from canary to stable, got %+v", rec)
+	}
+	if !slices.Equal(rec.Values, []string{"10.0.8.100"}) || rec.TTL != 300 || rec.Meta["description"] != "web" {
+		t.Errorf("expected the record to be kept as is, got %+v", rec)
+	}
+	if got := drainEvents(recorder); !slices.Contains(got, "Normal HandedOver kept A record app.my-domain1.com for prod/HTTPRoute/default/stable, which still claims it") {
+		t.Errorf("expected a HandedOver event, got %q", got)
+	}
+}
+
+func TestRouteReconciler_SharedHostnameKeepsOwner(t *testing.T) {
+	canary := newSharedRoute("canary", "app.my-domain1.com")
+	stable := newSharedRoute("stable", "app.my-domain1.com")
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"},
+				Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/stable"}},
+		},
+	}
+	reconciler, fakeClient := newClaimsReconciler(t, mock, nil, canary, stable)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "canary", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords)+len(mock.updatedRecords) != 0 {
+		t.Errorf("expected the shared record to be left alone, got creates %v, updates %v", mock.createdRecords, mock.updatedRecords)
+	}
+	var updated gatewayv1.HTTPRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if s := readStatus(&updated)["app.my-domain1.com"]; s.State != stateReady {
+		t.Errorf("expected the shared hostname to be Ready, got %+v", s)
+	}
+}
+
+func TestRouteReconciler_ConflictingClaim(t *testing.T) {
+	canary := newSharedRoute("canary", "app.my-domain1.com")
+	stable := newSharedRoute("stable", "app.my-domain1.com")
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.9.9"},
+				Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/stable"}},
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, fakeClient := newClaimsReconciler(t, mock, recorder, canary, stable)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "canary", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 0 {
+		t.Errorf("expected the other route's record to be left alone, got %v", mock.updatedRecords)
+	}
+	const msg = "A app.my-domain1.com is also claimed by HTTPRoute default/stable with values 10.0.9.9, this HTTPRoute wants 10.0.8.100"
+	if got := drainEvents(recorder); !slices.Equal(got, []string{"Warning Conflict " + msg}) {
+		t.Errorf("expected a Conflict event, got %q", got)
+	}
+	var updated gatewayv1.HTTPRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if s := readStatus(&updated)["app.my-domain1.com"]; s.State != stateConflict || s.Error != msg {
+		t.Errorf("expected the hostname to be in Conflict, got %+v", s)
+	}
+
+	// Once the other route is gone, the record is an orphan owned by someone
+	// else and is no longer reported as a conflict.
+	if err := fakeClient.Delete(context.Background(), stable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := drainEvents(recorder); slices.Contains(got, "Warning Conflict "+msg) {
+		t.Errorf("expected no Conflict event once the other route is gone, got %q", got)
+	}
+}
+
+func TestRouteReconciler_SameNameOfAnotherKind(t *testing.T) {
+	route := newSharedRoute("web", "app.my-domain1.com")
+	grpc := &gatewayv1.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Finalizers: []string{finalizerName}},
+		Spec:       gatewayv1.GRPCRouteSpec{Hostnames: []gatewayv1.Hostname{"app.my-domain1.com"}},
+	}
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.9.9"},
+				Meta: map[string]string{dns.MetaOwner: "prod/GRPCRoute/default/web"}},
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, _ := newClaimsReconciler(t, mock, recorder, route, grpc)
+	reconciler.Kinds = []RouteKind{HTTPRouteKind, GRPCRouteKind}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 0 {
+		t.Errorf("expected the GRPCRoute's record to be left alone, got %v", mock.updatedRecords)
+	}
+	const msg = "A app.my-domain1.com is also claimed by GRPCRoute default/web with values 10.0.9.9, this HTTPRoute wants 10.0.8.100"
+	if got := drainEvents(recorder); !slices.Equal(got, []string{"Warning Conflict " + msg}) {
+		t.Errorf("expected a Conflict event, got %q", got)
+	}
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	dnsv1alpha1 "github.com/yuriy-kovalchuk/yk-dns-manager/api/v1alpha1"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// dnsRecordKind labels DNSRecords in the controller metrics.
+const dnsRecordKind = "DNSRecord"
+
+// DNSRecordReconciler applies DNSRecord resources through the DNS provider
+// and reports the outcome in their Ready condition.
+type DNSRecordReconciler struct {
+	client.Client
+	Log     logr.Logger
+	DNS     dns.Provider
+	Cluster string          // cluster name used in record owner IDs
+	Health  *ProviderHealth // optional, told about authentication failures
+}
+
+// Reconcile applies a DNSRecord. Failures are requeued by their class, see
+// requeueError.
+func (r *DNSRecordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	result, err := r.reconcile(ctx, req)
+	if err != nil {
+		reconcileErrorsTotal.WithLabelValues(dnsRecordKind, failureReason(err)).Inc()
+	}
+	r.Health.Observe(err)
+	return result, requeueError(err)
+}
+
+func (r *DNSRecordReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var obj dnsv1alpha1.DNSRecord
+	if err := r.Get(ctx, req.NamespacedName, &obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	owner := dns.OwnerID(r.Cluster, dnsRecordKind, obj.Namespace, obj.Name)
+	recordType := strings.ToUpper(obj.Spec.Type)
+
+	if !obj.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(&obj, finalizerName) {
+			r.Log.Info("deleting DNS record for DNSRecord", "name", req.NamespacedName)
+			// Only a record the provider holds is deleted and counted.
+			exists, err := r.DNS.Exists(ctx, obj.Spec.Hostname, recordType)
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("checking DNS record: %w", err)
+			}
+			if exists {
+				changes := dns.ChangeSet{Deletes: []dns.Record{ownedKey(obj.Spec.Hostname, recordType, owner)}}
+				if err := r.DNS.ApplyChanges(ctx, changes); err != nil {
+					if !errors.Is(err, dns.ErrNotOwned) {
+						return ctrl.Result{}, fmt.Errorf("deleting DNS record: %w", err)
+					}
+					// Records created by someone else are left in place and must not block deletion.
+					r.Log.Error(err, "left DNS record owned by someone else in place", "name", req.NamespacedName)
+				} else {
+					countChanges(dnsRecordKind, changes, nil)
+				}
+			}
+
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				if err := r.Get(ctx, req.NamespacedName, &obj); err != nil {
+					return err
+				}
+				controllerutil.RemoveFinalizer(&obj, finalizerName)
+				return r.Update(ctx, &obj)
+			})
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to remove finalizer: %w", err)
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(&obj, finalizerName) {
+		controllerutil.AddFinalizer(&obj, finalizerName)
+		if err := r.Update(ctx, &obj); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	if recordType == "CNAME" && len(obj.Spec.Values) != 1 {
+		return ctrl.Result{}, r.setReady(ctx, &obj, metav1.ConditionFalse, dnsv1alpha1.ReasonInvalid,
+			fmt.Sprintf("a CNAME record has exactly one value, got %d", len(obj.Spec.Values)))
+	}
+	if _, unsupported := publishable(r.DNS, obj.Spec.Hostname, []target{{Type: recordType}}); len(unsupported) > 0 {
+		return ctrl.Result{}, r.setReady(ctx, &obj, metav1.ConditionFalse, dnsv1alpha1.ReasonInvalid,
+			unsupportedWildcardMessage(obj.Spec.Hostname, unsupported))
+	}
+
+	record := dns.Record{
+		Hostname: obj.Spec.Hostname,
+		Type:     recordType,
+		Values:   obj.Spec.Values,
+		TTL:      obj.Spec.TTL,
+		Meta: map[string]string{
+			"description": dns.ManagedDescription,
+			dns.MetaOwner: owner,
+		},
+	}
+
+	var changes dns.ChangeSet
+	exists, err := r.DNS.Exists(ctx, record.Hostname, record.Type)
+	if err == nil {
+		// The record is declared explicitly, so existing records are always updated.
+		if exists {
+			changes.Updates = append(changes.Updates, record)
+		} else {
+			changes.Creates = append(changes.Creates, record)
+		}
+		err = r.DNS.ApplyChanges(ctx, changes)
+	}
+	if err != nil {
+		reason := dnsv1alpha1.ReasonFailed
+		if errors.Is(err, dns.ErrNonRetryable) {
+			reason = dnsv1alpha1.ReasonInvalid
+		}
+		if statusErr := r.setReady(ctx, &obj, metav1.ConditionFalse, reason, err.Error()); statusErr != nil {
+			r.Log.Error(statusErr, "failed to record DNSRecord status", "name", req.NamespacedName)
+		}
+		if errors.Is(err, dns.ErrNotOwned) {
+			// Retrying won't help until someone resolves the conflicting record by hand.
+			r.Log.Error(err, "DNS record owned by someone else", "name", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("applying DNS record: %w", err)
+	}
+
+	countChanges(dnsRecordKind, changes, nil)
+	r.Log.Info("applied DNS record", "name", req.NamespacedName,
+		"hostname", record.Hostname, "type", record.Type, "values", record.Values)
+	return ctrl.Result{}, r.setReady(ctx, &obj, metav1.ConditionTrue, dnsv1alpha1.ReasonApplied, "record applied")
+}
+
+// setReady records the Ready condition and the observed generation in the
+// DNSRecord's status.
+func (r *DNSRecordReconciler) setReady(ctx context.Context, obj *dnsv1alpha1.DNSRecord, status metav1.ConditionStatus, reason, message string) error {
+	obj.Status.ObservedGeneration = obj.Generation
+	apimeta.SetStatusCondition(&obj.Status.Conditions, metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: obj.Generation,
+	})
+	if err := r.Status().Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to update DNSRecord status: %w", err)
+	}
+	return nil
+}
+
+func (r *DNSRecordReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(crcontroller.Options{RateLimiter: newRateLimiter()}).
+		For(&dnsv1alpha1.DNSRecord{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	dnsv1alpha1 "github.com/yuriy-kovalchuk/yk-dns-manager/api/v1alpha1"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+func newTestDNSRecord(values ...string) *dnsv1alpha1.DNSRecord {
+	return &dnsv1alpha1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "default", Generation: 1},
+		Spec: dnsv1alpha1.DNSRecordSpec{
+			Hostname: "legacy.my-domain1.com",
+			Type:     "cname",
+			Values:   values,
+			TTL:      600,
+		},
+	}
+}
+
+func newRecordReconciler(t *testing.T, mock *mockDNSProvider, obj *dnsv1alpha1.DNSRecord) (*DNSRecordReconciler, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := dnsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to install dns.yk scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(obj).
+		WithStatusSubresource(obj).
+		Build()
+	return &DNSRecordReconciler{
+		Client:  c,
+		Log:     zap.New(zap.UseDevMode(true)),
+		DNS:     mock,
+		Cluster: "prod",
+	}, c
+}
+
+func readyCondition(t *testing.T, c client.Client) *metav1.Condition {
+	t.Helper()
+	var obj dnsv1alpha1.DNSRecord
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "legacy", Namespace: "default"}, &obj); err != nil {
+		t.Fatal(err)
+	}
+	return apimeta.FindStatusCondition(obj.Status.Conditions, dnsv1alpha1.ConditionReady)
+}
+
+func TestDNSRecordReconciler_Applies(t *testing.T) {
+	mock := &mockDNSProvider{}
+	reconciler, c := newRecordReconciler(t, mock, newTestDNSRecord("old-box.lan"))
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected 1 created record, got %v", mock.createdRecords)
+	}
+	rec := mock.createdRecords[0]
+	if rec.Type != "CNAME" || !slices.Equal(rec.Values, []string{"old-box.lan"}) || rec.TTL != 600 {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if rec.Owner() != "prod/DNSRecord/default/legacy" {
+		t.Errorf("expected owner 'prod/DNSRecord/default/legacy', got %q", rec.Owner())
+	}
+
+	cond := readyCondition(t, c)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != dnsv1alpha1.ReasonApplied {
+		t.Errorf("expected Ready=True/Applied, got %+v", cond)
+	}
+}
+
+func TestDNSRecordReconciler_ProviderError(t *testing.T) {
+	mock := &mockDNSProvider{applyErr: errors.New("opnsense: unsupported record type")}
+	reconciler, c := newRecordReconciler(t, mock, newTestDNSRecord("old-box.lan"))
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err == nil {
+		t.Fatal("expected provider error to be returned for retry")
+	}
+
+	cond := readyCondition(t, c)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != dnsv1alpha1.ReasonFailed {
+		t.Fatalf("expected Ready=False/Failed, got %+v", cond)
+	}
+	if !strings.Contains(cond.Message, "unsupported record type") {
+		t.Errorf("expected provider error in condition message, got %q", cond.Message)
+	}
+}
+
+func TestDNSRecordReconciler_ProviderErrorWithStatusFailure(t *testing.T) {
+	mock := &mockDNSProvider{applyErr: fmt.Errorf("%w: bad API key", dns.ErrAuthFailed)}
+	reconciler, _ := newRecordReconciler(t, mock, newTestDNSRecord("old-box.lan"))
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		SubResourceUpdate: func(context.Context, client.Client, string, client.Object, ...client.SubResourceUpdateOption) error {
+			return errors.New("apiserver unavailable")
+		},
+	})
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	_, err := reconciler.Reconcile(context.Background(), req)
+	if !errors.Is(err, dns.ErrAuthFailed) {
+		t.Fatalf("expected the provider error to be returned over the status error, got %v", err)
+	}
+}
+
+func TestDNSRecordReconciler_NotOwned(t *testing.T) {
+	mock := &mockDNSProvider{applyErr: dns.ErrNotOwned}
+	reconciler, c := newRecordReconciler(t, mock, newTestDNSRecord("old-box.lan"))
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("expected ownership conflict not to be retried, got %v", err)
+	}
+
+	if cond := readyCondition(t, c); cond == nil || cond.Status != metav1.ConditionFalse {
+		t.Errorf("expected Ready=False, got %+v", cond)
+	}
+}
+
+func TestDNSRecordReconciler_CNAMEMultipleValuesInvalid(t *testing.T) {
+	mock := &mockDNSProvider{}
+	reconciler, c := newRecordReconciler(t, mock, newTestDNSRecord("10.0.0.1", "10.0.0.2"))
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 0 {
+		t.Errorf("expected no DNS changes, got %v", mock.applied)
+	}
+	if cond := readyCondition(t, c); cond == nil || cond.Reason != dnsv1alpha1.ReasonInvalid {
+		t.Errorf("expected Ready=False/Invalid, got %+v", cond)
+	}
+}
+
+func TestDNSRecordReconciler_UnsupportedWildcardInvalid(t *testing.T) {
+	obj := newTestDNSRecord("gateway.my-domain1.com")
+	obj.Spec.Hostname = "*.legacy.my-domain1.com"
+	mock := &mockDNSProvider{wildcardTypes: []string{"A", "AAAA"}}
+	reconciler, c := newRecordReconciler(t, mock, obj)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 0 {
+		t.Errorf("expected no DNS changes, got %v", mock.applied)
+	}
+	if cond := readyCondition(t, c); cond == nil || cond.Reason != dnsv1alpha1.ReasonInvalid || !strings.Contains(cond.Message, "wildcard CNAME") {
+		t.Errorf("expected Ready=False/Invalid for the wildcard CNAME, got %+v", cond)
+	}
+}
+
+func TestDNSRecordReconciler_MultipleValues(t *testing.T) {
+	obj := newTestDNSRecord("10.0.0.1", "10.0.0.2")
+	obj.Spec.Type = "A"
+	mock := &mockDNSProvider{}
+	reconciler, c := newRecordReconciler(t, mock, obj)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected 1 created record, got %d", len(mock.createdRecords))
+	}
+	if rec := mock.createdRecords[0]; rec.Type != "A" || !slices.Equal(rec.Values, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("expected A record with both values, got %s %v", rec.Type, rec.Values)
+	}
+	if cond := readyCondition(t, c); cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected Ready=True, got %+v", cond)
+	}
+}
+
+func TestDNSRecordReconciler_Deletion(t *testing.T) {
+	now := metav1.Now()
+	obj := newTestDNSRecord("old-box.lan")
+	obj.Finalizers = []string{finalizerName}
+	obj.DeletionTimestamp = &now
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{{Hostname: "legacy.my-domain1.com", Type: "CNAME", Values: []string{"old-box.lan"},
+			Meta: map[string]string{dns.MetaOwner: "prod/DNSRecord/default/legacy"}}},
+	}
+	reconciler, c := newRecordReconciler(t, mock, obj)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 1 || len(mock.applied[0].Deletes) != 1 {
+		t.Fatalf("expected one delete, got %v", mock.applied)
+	}
+	if del := mock.applied[0].Deletes[0]; del.Hostname != "legacy.my-domain1.com" || del.Type != "CNAME" {
+		t.Errorf("unexpected delete %+v", del)
+	}
+
+	var updated dnsv1alpha1.DNSRecord
+	err := c.Get(context.Background(), req.NamespacedName, &updated)
+	if err == nil && len(updated.Finalizers) != 0 {
+		t.Errorf("expected finalizer to be removed, got %v", updated.Finalizers)
+	}
+}
+
+func TestDNSRecordReconciler_DeletionWithoutRecord(t *testing.T) {
+	now := metav1.Now()
+	obj := newTestDNSRecord("old-box.lan")
+	obj.Finalizers = []string{finalizerName}
+	obj.DeletionTimestamp = &now
+
+	mock := &mockDNSProvider{}
+	reconciler, _ := newRecordReconciler(t, mock, obj)
+	deleted := testutil.ToFloat64(recordChangesTotal.WithLabelValues(dnsRecordKind, changeDeleted))
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 0 {
+		t.Errorf("expected no delete for a record that doesn't exist, got %v", mock.applied)
+	}
+	if got := testutil.ToFloat64(recordChangesTotal.WithLabelValues(dnsRecordKind, changeDeleted)); got != deleted {
+		t.Errorf("expected no deletion to be counted, got %v more", got-deleted)
+	}
+}
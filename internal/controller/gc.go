@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dnsv1alpha1 "github.com/yuriy-kovalchuk/yk-dns-manager/api/v1alpha1"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Orphan actions used in logs and the orphan records metric.
+const (
+	orphanDeleted  = "deleted"
+	orphanReported = "reported"
+	orphanDeferred = "deferred"
+	orphanSkipped  = "skipped"
+)
+
+// OrphanCollector periodically deletes records owned by this cluster whose
+// route no longer exists or no longer claims them, e.g. after a domain map
+// change or a route force-deleted without its finalizer.
+//
+// A record is only deleted after it has been seen orphaned for at least MinAge
+// across consecutive runs, and at most MaxDeletions records are deleted per
+// run. In DryRun mode orphans are only reported.
+type OrphanCollector struct {
+	client.Client
+	Log          logr.Logger
+	Resolver     *Resolver
+	DNS          dns.Provider
+	Cluster      string      // only records owned by this cluster are considered
+	Kinds        []RouteKind // route kinds whose claims are honoured, defaults to HTTPRoute
+	DNSRecords   bool        // also honour the claims of DNSRecord resources
+	Interval     time.Duration
+	MinAge       time.Duration
+	MaxDeletions int // 0 means no limit
+	DryRun       bool
+
+	mu        sync.Mutex
+	firstSeen map[string]time.Time // orphan key → time it was first seen orphaned
+	now       func() time.Time
+}
+
+// Start runs the garbage collection loop until the context is cancelled.
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	c.Log.Info("starting orphaned record garbage collection",
+		"interval", c.Interval, "minAge", c.MinAge, "maxDeletions", c.MaxDeletions, "dryRun", c.DryRun)
+	runPeriodically(ctx, c.Log, c.Interval, c.Collect)
+	return nil
+}
+
+// NeedLeaderElection ensures only the leader deletes records.
+func (c *OrphanCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect performs a single garbage collection pass.
+func (c *OrphanCollector) Collect(ctx context.Context) error {
+	claims, err := c.routeClaims(ctx)
+	if err != nil {
+		return err
+	}
+
+	records, err := c.DNS.List(ctx, dns.ListFilter{})
+	if err != nil {
+		return fmt.Errorf("listing DNS records: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.firstSeen == nil {
+		c.firstSeen = make(map[string]time.Time)
+	}
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+
+	var orphans []dns.Record
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		if !c.ownedHere(rec) || c.isClaimed(claims, rec.Owner(), rec.Hostname) {
+			continue
+		}
+		key := orphanKey(rec)
+		seen[key] = true
+		first, tracked := c.firstSeen[key]
+		if !tracked {
+			c.firstSeen[key] = now
+			first = now
+		}
+		if now.Sub(first) < c.MinAge {
+			c.Log.V(1).Info("orphaned record within grace period",
+				"hostname", rec.Hostname, "type", rec.Type, "owner", rec.Owner(), "age", now.Sub(first))
+			continue
+		}
+		orphans = append(orphans, rec)
+	}
+	// Forget records that are no longer orphaned, so a later orphaning restarts the grace period.
+	for key := range c.firstSeen {
+		if !seen[key] {
+			delete(c.firstSeen, key)
+		}
+	}
+
+	if len(orphans) == 0 {
+		c.Log.V(1).Info("no orphaned records found")
+		return nil
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphanKey(orphans[i]) < orphanKey(orphans[j]) })
+
+	if c.DryRun {
+		for _, rec := range orphans {
+			c.Log.Info("found orphaned record (dry run)", "hostname", rec.Hostname, "type", rec.Type,
+				"values", rec.Values, "owner", rec.Owner(), "action", orphanReported)
+			orphanRecordsTotal.WithLabelValues(orphanReported).Inc()
+		}
+		return nil
+	}
+
+	if c.MaxDeletions > 0 && len(orphans) > c.MaxDeletions {
+		for _, rec := range orphans[c.MaxDeletions:] {
+			c.Log.Info("deferring orphaned record deletion, per-run limit reached", "hostname", rec.Hostname,
+				"type", rec.Type, "owner", rec.Owner(), "limit", c.MaxDeletions, "action", orphanDeferred)
+			orphanRecordsTotal.WithLabelValues(orphanDeferred).Inc()
+		}
+		orphans = orphans[:c.MaxDeletions]
+	}
+
+	var changes dns.ChangeSet
+	for _, rec := range orphans {
+		changes.Deletes = append(changes.Deletes, dns.Record{
+			Hostname: rec.Hostname,
+			Type:     rec.Type,
+			Meta:     map[string]string{dns.MetaOwner: rec.Owner()},
+		})
+	}
+	err = c.DNS.ApplyChanges(ctx, changes)
+	if err != nil && !errors.Is(err, dns.ErrNotOwned) {
+		return fmt.Errorf("deleting orphaned records: %w", err)
+	}
+	// Records the provider refused changed owner since they were listed. They
+	// stay tracked, so the next run looks at them again.
+	refused := refusedRecords(err)
+	for _, rec := range orphans {
+		if e := refused[recordKey(rec)]; e != nil {
+			c.Log.Info("skipped orphaned record owned by someone else", "hostname", rec.Hostname,
+				"type", rec.Type, "owner", e.Owner, "action", orphanSkipped)
+			orphanRecordsTotal.WithLabelValues(orphanSkipped).Inc()
+			continue
+		}
+		c.Log.Info("deleted orphaned record", "hostname", rec.Hostname, "type", rec.Type,
+			"values", rec.Values, "owner", rec.Owner(), "action", orphanDeleted)
+		orphanRecordsTotal.WithLabelValues(orphanDeleted).Inc()
+		delete(c.firstSeen, orphanKey(rec))
+	}
+	return nil
+}
+
+// ownedHere reports whether a record is tagged with an owner ID from this
+// cluster, including legacy owner IDs without the kind.
+func (c *OrphanCollector) ownedHere(rec dns.Record) bool {
+	parts := strings.Split(rec.Owner(), "/")
+	return (len(parts) == 3 || len(parts) == 4) && parts[0] == c.Cluster
+}
+
+// isClaimed reports whether the live object with the given owner ID still
+// claims the hostname.
+func (c *OrphanCollector) isClaimed(claims map[string][]string, owner, hostname string) bool {
+	return Contains(claims[owner], strings.ToLower(strings.TrimSuffix(hostname, ".")))
+}
+
+// routeClaims maps the owner ID of every live route to the managed hostnames
+// it claims, taken from both its spec and its managed-hostnames annotation.
+// Hostnames that are no longer managed, e.g. because their domain left the
+// domain map, are not claimed. Routes being deleted keep their claims, since
+// their finalizer is responsible for cleaning up. Claims are also recorded
+// under the legacy owner ID, so records written before owner IDs included the
+// kind are not collected while a route still claims them.
+func (c *OrphanCollector) routeClaims(ctx context.Context) (map[string][]string, error) {
+	claims := make(map[string][]string)
+	claim := func(owner, hostname string) {
+		claims[owner] = append(claims[owner], hostname)
+		legacy := dns.LegacyOwnerID(owner)
+		claims[legacy] = append(claims[legacy], hostname)
+	}
+	for _, kind := range orDefaultKinds(c.Kinds) {
+		routes := kind.newList()
+		if err := c.List(ctx, routes); err != nil {
+			return nil, fmt.Errorf("listing %ss: %w", kind.Kind, err)
+		}
+
+		for _, route := range kind.items(routes) {
+			hostnames := kind.hostnames(route)
+			if val, ok := route.GetAnnotations()[managedHostnamesAnnotation]; ok {
+				var managed []string
+				_ = json.Unmarshal([]byte(val), &managed)
+				hostnames = append(hostnames, managed...)
+			}
+			owner := dns.OwnerID(c.Cluster, kind.Kind, route.GetNamespace(), route.GetName())
+			for _, h := range hostnames {
+				h = strings.ToLower(strings.TrimSuffix(h, "."))
+				if c.Resolver.Manages(kind, h) {
+					claim(owner, h)
+				}
+			}
+		}
+	}
+
+	if c.DNSRecords {
+		var records dnsv1alpha1.DNSRecordList
+		if err := c.List(ctx, &records); err != nil {
+			return nil, fmt.Errorf("listing DNSRecords: %w", err)
+		}
+		for _, rec := range records.Items {
+			owner := dns.OwnerID(c.Cluster, dnsRecordKind, rec.Namespace, rec.Name)
+			claim(owner, strings.ToLower(strings.TrimSuffix(rec.Spec.Hostname, ".")))
+		}
+	}
+	return claims, nil
+}
+
+// orphanKey identifies a record across garbage collection runs.
+func orphanKey(rec dns.Record) string {
+	return strings.ToLower(rec.Hostname) + "|" + strings.ToUpper(rec.Type) + "|" + rec.Owner()
+}
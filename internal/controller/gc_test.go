@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	dnsv1alpha1 "github.com/yuriy-kovalchuk/yk-dns-manager/api/v1alpha1"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+func newCollector(t *testing.T, mock *mockDNSProvider, routes ...*gatewayv1.HTTPRoute) *OrphanCollector {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, route := range routes {
+		builder = builder.WithObjects(route)
+	}
+
+	return &OrphanCollector{
+		Client:   builder.Build(),
+		Log:      zap.New(zap.UseDevMode(true)),
+		Resolver: newTestResolver(t),
+		DNS:      mock,
+		Cluster:  "prod",
+		Interval: time.Minute,
+	}
+}
+
+func ownedRecord(hostname, owner string) dns.Record {
+	return dns.Record{
+		Hostname: hostname,
+		Type:     "A",
+		Values:   []string{"10.0.8.100"},
+		Meta:     map[string]string{dns.MetaOwner: owner},
+	}
+}
+
+func TestOrphanCollector_DeletesOrphans(t *testing.T) {
+	route := managedRoute("web", "app.my-domain1.com")
+	route.Annotations = map[string]string{managedHostnamesAnnotation: `["old.my-domain1.com"]`}
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			ownedRecord("app.my-domain1.com", "prod/HTTPRoute/default/web"),           // claimed by spec
+			ownedRecord("old.my-domain1.com", "prod/Ingress/default/web"),             // claimed by annotation of another kind
+			ownedRecord("api.my-domain1.com", "prod/HTTPRoute/default/web"),           // no longer claimed
+			ownedRecord("gone.my-domain1.com", "prod/HTTPRoute/default/gone"),         // route deleted
+			ownedRecord("app.unknown.com", "prod/HTTPRoute/default/web"),              // dropped from the domain map
+			ownedRecord("other.my-domain1.com", "staging/HTTPRoute/default/x"),        // another cluster
+			{Hostname: "nas.my-domain1.com", Type: "A", Values: []string{"10.0.0.5"}}, // hand-made
+			{Hostname: "app.my-domain1.com", Type: "AAAA", Values: []string{"fd00::1"}, // legacy owner ID
+				Meta: map[string]string{dns.MetaOwner: "prod/default/web"}},
+		},
+	}
+	collector := newCollector(t, mock, route)
+
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"api.my-domain1.com", "app.unknown.com", "gone.my-domain1.com", "old.my-domain1.com"}
+	if len(mock.deletedHosts) != len(want) {
+		t.Fatalf("expected deleted hosts %v, got %v", want, mock.deletedHosts)
+	}
+	for i, h := range want {
+		if mock.deletedHosts[i] != h {
+			t.Errorf("expected deleted host %d to be %q, got %q", i, h, mock.deletedHosts[i])
+		}
+	}
+}
+
+func TestOrphanCollector_DryRun(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{ownedRecord("gone.my-domain1.com", "prod/HTTPRoute/default/gone")},
+	}
+	collector := newCollector(t, mock)
+	collector.DryRun = true
+
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 0 {
+		t.Errorf("expected no changes in dry run, got %v", mock.applied)
+	}
+}
+
+func TestOrphanCollector_MinAge(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{ownedRecord("gone.my-domain1.com", "prod/HTTPRoute/default/gone")},
+	}
+	collector := newCollector(t, mock)
+	collector.MinAge = 10 * time.Minute
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	collector.now = func() time.Time { return now }
+
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.deletedHosts) != 0 {
+		t.Fatalf("expected no deletion within grace period, got %v", mock.deletedHosts)
+	}
+
+	now = now.Add(11 * time.Minute)
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.deletedHosts) != 1 {
+		t.Fatalf("expected 1 deletion after grace period, got %v", mock.deletedHosts)
+	}
+}
+
+func TestOrphanCollector_MaxDeletions(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			ownedRecord("a.my-domain1.com", "prod/HTTPRoute/default/gone"),
+			ownedRecord("b.my-domain1.com", "prod/HTTPRoute/default/gone"),
+			ownedRecord("c.my-domain1.com", "prod/HTTPRoute/default/gone"),
+		},
+	}
+	collector := newCollector(t, mock)
+	collector.MaxDeletions = 2
+
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.deletedHosts) != 2 {
+		t.Fatalf("expected 2 deletions, got %v", mock.deletedHosts)
+	}
+}
+
+func TestOrphanCollector_SkipsRefusedRecords(t *testing.T) {
+	taken := ownedRecord("taken.my-domain1.com", "prod/HTTPRoute/default/gone")
+	refused := &dns.OwnershipError{Hostname: taken.Hostname, Type: "A", Owner: "prod/HTTPRoute/default/new", Wanted: taken.Owner()}
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{ownedRecord("gone.my-domain1.com", "prod/HTTPRoute/default/gone"), taken},
+		applyErr:      fmt.Errorf("opnsense: %w", refused),
+	}
+	collector := newCollector(t, mock)
+	skipped := testutil.ToFloat64(orphanRecordsTotal.WithLabelValues(orphanSkipped))
+
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := testutil.ToFloat64(orphanRecordsTotal.WithLabelValues(orphanSkipped)); got != skipped+1 {
+		t.Errorf("expected the refused record to be counted as skipped, got %v more", got-skipped)
+	}
+	if _, ok := collector.firstSeen[orphanKey(taken)]; !ok {
+		t.Errorf("expected the refused record to stay tracked")
+	}
+	if _, ok := collector.firstSeen[orphanKey(mock.listedRecords[0])]; ok {
+		t.Errorf("expected the deleted record to be forgotten")
+	}
+}
+
+func TestOrphanCollector_KeepsDNSRecordClaims(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			ownedRecord("legacy.my-domain1.com", "prod/DNSRecord/default/legacy"),
+			ownedRecord("removed.my-domain1.com", "prod/DNSRecord/default/removed"),
+		},
+	}
+	collector := newCollector(t, mock)
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := dnsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	collector.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestDNSRecord("old-box.lan")).Build()
+	collector.DNSRecords = true
+
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.deletedHosts) != 1 || mock.deletedHosts[0] != "removed.my-domain1.com" {
+		t.Errorf("expected only the record of the deleted DNSRecord to be removed, got %v", mock.deletedHosts)
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// defaultRecheckInterval is how often a degraded provider is health checked
+// again when ProviderHealth.RecheckInterval is not set.
+const defaultRecheckInterval = 30 * time.Second
+
+// ProviderHealth is a readiness check reporting the controller degraded while
+// the DNS provider rejects its credentials. Reconcilers report such failures
+// through Observe; once degraded, the check runs the provider's HealthCheck
+// every RecheckInterval and reports ready again as soon as it passes, e.g.
+// after the credentials were fixed and the provider config reloaded.
+type ProviderHealth struct {
+	DNS             dns.Provider
+	RecheckInterval time.Duration
+
+	mu      sync.Mutex
+	err     error     // last authentication failure, nil while healthy
+	checked time.Time // when the provider was last health checked
+}
+
+// Observe records err, returned by the provider, if it is an authentication
+// failure. Other errors say nothing about the credentials and are ignored.
+// A nil ProviderHealth ignores every error.
+func (h *ProviderHealth) Observe(err error) {
+	if h == nil || !errors.Is(err, dns.ErrAuthFailed) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == nil {
+		h.checked = time.Now()
+	}
+	h.err = err
+}
+
+// Check implements healthz.Checker. It fails while the provider rejects the
+// credentials.
+func (h *ProviderHealth) Check(req *http.Request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == nil {
+		return nil
+	}
+	interval := h.RecheckInterval
+	if interval <= 0 {
+		interval = defaultRecheckInterval
+	}
+	if time.Since(h.checked) >= interval {
+		h.checked = time.Now()
+		err := h.DNS.HealthCheck(req.Context())
+		if err == nil {
+			h.err = nil
+			return nil
+		}
+		if errors.Is(err, dns.ErrAuthFailed) {
+			h.err = err
+		}
+	}
+	return fmt.Errorf("DNS provider degraded: %w", h.err)
+}
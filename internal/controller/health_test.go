@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// newProbeRequest returns a readiness probe request.
+func newProbeRequest() *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/readyz", nil)
+}
+
+func TestProviderHealth(t *testing.T) {
+	authErr := dns.Classify(errors.New("opnsense: searchHostOverride returned status 403"), dns.ErrAuthFailed)
+	mock := &mockDNSProvider{healthErr: authErr}
+	health := &ProviderHealth{DNS: mock, RecheckInterval: time.Hour}
+
+	health.Observe(errors.New("timeout"))
+	if err := health.Check(newProbeRequest()); err != nil {
+		t.Fatalf("expected other errors to leave the provider healthy, got %v", err)
+	}
+
+	health.Observe(authErr)
+	if err := health.Check(newProbeRequest()); !errors.Is(err, dns.ErrAuthFailed) {
+		t.Fatalf("expected the provider to be degraded, got %v", err)
+	}
+
+	// The provider is checked again once the recheck interval has passed.
+	health.checked = time.Now().Add(-2 * time.Hour)
+	if err := health.Check(newProbeRequest()); !errors.Is(err, dns.ErrAuthFailed) {
+		t.Fatalf("expected the provider to stay degraded while its health check fails, got %v", err)
+	}
+	mock.healthErr = nil
+	if err := health.Check(newProbeRequest()); err == nil {
+		t.Fatal("expected no health check before the recheck interval passed")
+	}
+	health.checked = time.Now().Add(-2 * time.Hour)
+	if err := health.Check(newProbeRequest()); err != nil {
+		t.Errorf("expected the provider to be healthy again once its health check passes, got %v", err)
+	}
+
+	var unset *ProviderHealth
+	unset.Observe(authErr)
+}
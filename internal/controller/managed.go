@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// RecordCounter periodically lists the records on the DNS server and updates
+// the managed records and last sync metrics. It keeps them current when the
+// drift resync, which otherwise updates them, is disabled.
+type RecordCounter struct {
+	Log      logr.Logger
+	DNS      dns.Provider
+	Cluster  string // cluster name used in record owner IDs
+	Interval time.Duration
+	// Zones returns the zones managed records are counted in, optional.
+	Zones func() dns.Zones
+}
+
+// Start counts the records once, then every interval until the context is
+// cancelled.
+func (c *RecordCounter) Start(ctx context.Context) error {
+	c.Log.Info("starting managed record count", "interval", c.Interval)
+	if err := c.Count(ctx); err != nil {
+		c.Log.Error(err, "periodic run failed")
+	}
+	runPeriodically(ctx, c.Log, c.Interval, c.Count)
+	return nil
+}
+
+// NeedLeaderElection ensures only the leader reports managed records, like
+// the drift resync.
+func (c *RecordCounter) NeedLeaderElection() bool {
+	return true
+}
+
+// Count performs a single count of the managed records.
+func (c *RecordCounter) Count(ctx context.Context) error {
+	records, err := c.DNS.List(ctx, dns.ListFilter{})
+	if err != nil {
+		return fmt.Errorf("listing DNS records: %w", err)
+	}
+	countManaged(records, c.Cluster, c.Zones)
+	lastSyncTimestamp.SetToCurrentTime()
+	return nil
+}
+
+// countManaged sets the managed records metric to the records owned by
+// cluster, by zone and type. Round-robin values listed as separate records
+// count as one record.
+func countManaged(records []dns.Record, cluster string, zonesFunc func() dns.Zones) {
+	var zones dns.Zones
+	if zonesFunc != nil {
+		zones = zonesFunc()
+	}
+	type key struct{ zone, recordType string }
+	seen := make(map[string]bool, len(records))
+	counts := make(map[key]int)
+	for _, rec := range records {
+		if !strings.HasPrefix(rec.Owner(), cluster+"/") || seen[recordKey(rec)] {
+			continue
+		}
+		seen[recordKey(rec)] = true
+		_, zone := zones.Split(rec.Hostname)
+		counts[key{zone, strings.ToUpper(rec.Type)}]++
+	}
+	managedRecords.Reset()
+	for k, n := range counts {
+		managedRecords.WithLabelValues(k.zone, k.recordType).Set(float64(n))
+	}
+}
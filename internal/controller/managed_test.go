@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+func TestRecordCounter_Count(t *testing.T) {
+	owned := map[string]string{dns.MetaOwner: "prod/DNSRecord/default/nas"}
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "nas.my-domain1.com", Type: "A", Values: []string{"10.0.8.5"}, Meta: owned},
+			{Hostname: "nas.my-domain1.com", Type: "A", Values: []string{"10.0.8.6"}, Meta: owned},
+			{Hostname: "web.my-domain1.com", Type: "CNAME", Values: []string{"nas.my-domain1.com"}, Meta: owned},
+			{Hostname: "db.my-domain1.com", Type: "A", Values: []string{"10.0.8.7"}},
+		},
+	}
+	zones, err := dns.NewZones("my-domain1.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &RecordCounter{
+		Log:     zap.New(zap.UseDevMode(true)),
+		DNS:     mock,
+		Cluster: "prod",
+		Zones:   func() dns.Zones { return zones },
+	}
+	managedRecords.WithLabelValues("stale.example.com", "A").Set(3)
+
+	before := time.Now().Unix()
+	if err := counter.Count(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := testutil.ToFloat64(managedRecords.WithLabelValues("my-domain1.com", "A")); got != 1 {
+		t.Errorf("expected 1 managed A record, got %v", got)
+	}
+	if got := testutil.ToFloat64(managedRecords.WithLabelValues("my-domain1.com", "CNAME")); got != 1 {
+		t.Errorf("expected 1 managed CNAME record, got %v", got)
+	}
+	if n := testutil.CollectAndCount(managedRecords); n != 2 {
+		t.Errorf("expected series for the current records only, got %d", n)
+	}
+	if got := testutil.ToFloat64(lastSyncTimestamp); got < float64(before) {
+		t.Errorf("expected the last sync timestamp to be set, got %v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+var driftCorrectionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_drift_corrections_total",
+		Help: "Number of DNS records found drifted from the desired state, by action taken.",
+	},
+	[]string{"action"},
+)
+
+var orphanRecordsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_orphan_records_total",
+		Help: "Number of orphaned DNS records handled by garbage collection, by action taken.",
+	},
+	[]string{"action"},
+)
+
+var unchangedRecordsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "yk_dns_unchanged_records_total",
+		Help: "Number of upserts skipped because the DNS record already matched the desired state.",
+	},
+)
+
+var hostnameConflictsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "yk_dns_hostname_conflicts_total",
+		Help: "Number of times a route claimed a hostname that another route holds with different values.",
+	},
+)
+
+var configReloadsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_config_reloads_total",
+		Help: "Number of domain map and provider config reloads, by result.",
+	},
+	[]string{"result"},
+)
+
+var configLastReloadSuccessful = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "yk_dns_config_last_reload_successful",
+		Help: "Whether the last config reload was applied (1) or rejected, keeping the previous config (0).",
+	},
+)
+
+var recordChangesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_record_changes_total",
+		Help: "Number of DNS records created, updated or deleted for routes and DNSRecords, by resource kind and action.",
+	},
+	[]string{"kind", "action"},
+)
+
+var reconcileErrorsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_reconcile_errors_total",
+		Help: "Number of failed reconciles, by resource kind and reason (Failed, Rejected or AuthFailed).",
+	},
+	[]string{"kind", "reason"},
+)
+
+var managedRecords = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "yk_dns_managed_records",
+		Help: "Number of DNS records owned by this cluster found on the DNS server at the last drift resync or managed record count, by zone and record type.",
+	},
+	[]string{"zone", "type"},
+)
+
+var lastSyncTimestamp = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "yk_dns_last_sync_timestamp_seconds",
+		Help: "Unix time of the last drift resync or managed record count that completed without error.",
+	},
+)
+
+// Record change actions used in the record changes metric.
+const (
+	changeCreated = "created"
+	changeUpdated = "updated"
+	changeDeleted = "deleted"
+)
+
+// countChanges adds the changes applied for an object of kind to the record
+// changes metric, skipping records the provider refused.
+func countChanges(kind string, changes dns.ChangeSet, refused map[string]*dns.OwnershipError) {
+	for action, recs := range map[string][]dns.Record{changeCreated: changes.Creates, changeUpdated: changes.Updates, changeDeleted: changes.Deletes} {
+		for _, rec := range recs {
+			if refused[recordKey(rec)] == nil {
+				recordChangesTotal.WithLabelValues(kind, action).Inc()
+			}
+		}
+	}
+}
+
+func init() {
+	metrics.Registry.MustRegister(driftCorrectionsTotal, orphanRecordsTotal, unchangedRecordsTotal, hostnameConflictsTotal, configReloadsTotal, configLastReloadSuccessful,
+		recordChangesTotal, reconcileErrorsTotal, managedRecords, lastSyncTimestamp)
+}
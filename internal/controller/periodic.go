@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// runPeriodically calls fn every interval until the context is cancelled.
+// Errors are logged and do not stop the loop.
+func runPeriodically(ctx context.Context, log logr.Logger, interval time.Duration, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Error(err, "periodic run failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Config reload results used in logs and the config reload metrics.
+const (
+	reloadSucceeded = "success"
+	reloadFailed    = "failure"
+)
+
+// reloadDebounce groups the burst of file events caused by a single ConfigMap
+// update into one reload.
+const reloadDebounce = time.Second
+
+// ConfigReloader watches the domain map and provider config files and applies
+// valid changes while the controller runs. A new domain map is swapped into the
+// Resolver, changed provider settings replace the DNS provider and changed DNS
+// zones are passed to it, after which every route is queued again. A file that
+// doesn't parse, or provider settings that fail the health check, are rejected
+// and the previous configuration stays in effect. Other provider options, such
+// as route kinds or the value source, are wired into the controllers at startup
+// and still need a restart.
+type ConfigReloader struct {
+	Log           logr.Logger
+	DomainMapPath string
+	ProviderPath  string
+	Provider      *config.ProviderConfig // provider config in effect at startup
+	Resolver      *Resolver
+	DNS           *dns.Swappable
+	// NewProvider creates and health-checks the DNS provider for a changed config.
+	NewProvider func(context.Context, *config.ProviderConfig) (dns.Provider, error)
+	Routes      []*RouteReconciler // reconcilers whose objects are queued after a change
+}
+
+// Start watches the config files until the context is cancelled. Kubernetes
+// updates mounted ConfigMaps by swapping a symlink, so the directories holding
+// the files are watched rather than the files themselves.
+func (r *ConfigReloader) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	dirs := map[string]bool{}
+	for _, path := range []string{r.DomainMapPath, r.ProviderPath} {
+		dir := filepath.Dir(path)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// The domain map is optional with the gateway value source.
+				r.Log.Info("not watching missing config directory", "dir", dir)
+				continue
+			}
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+	}
+
+	r.Log.Info("watching config files for changes", "domainMap", r.DomainMapPath, "provider", r.ProviderPath)
+	configLastReloadSuccessful.Set(1)
+
+	timer := time.NewTimer(reloadDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			r.Log.V(1).Info("config file event", "name", event.Name, "op", event.Op.String())
+			timer.Reset(reloadDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.Log.Error(err, "config watcher error")
+		case <-timer.C:
+			if err := r.Reload(ctx); err != nil {
+				r.Log.Error(err, "config reload rejected, keeping the previous configuration")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false so that standby replicas keep their
+// configuration current as well.
+func (r *ConfigReloader) NeedLeaderElection() bool {
+	return false
+}
+
+// Reload reads both config files and applies what changed. Nothing is applied
+// unless both files are valid.
+func (r *ConfigReloader) Reload(ctx context.Context) error {
+	if err := r.reload(ctx); err != nil {
+		configReloadsTotal.WithLabelValues(reloadFailed).Inc()
+		configLastReloadSuccessful.Set(0)
+		return err
+	}
+	configReloadsTotal.WithLabelValues(reloadSucceeded).Inc()
+	configLastReloadSuccessful.Set(1)
+	return nil
+}
+
+func (r *ConfigReloader) reload(ctx context.Context) error {
+	cfg, err := config.LoadProviderConfigFromPath(r.ProviderPath)
+	if err != nil {
+		return err
+	}
+	domainMap, err := r.loadDomainMap()
+	if err != nil {
+		return err
+	}
+
+	if needsRestart(r.Provider, cfg) {
+		r.Log.Info("provider config options other than provider, settings, zones, retry, rate_limit and circuit_breaker changed, they take effect after a restart")
+	}
+	providerChanged := cfg.Provider != r.Provider.Provider || !maps.Equal(cfg.Settings, r.Provider.Settings) ||
+		cfg.Retry != r.Provider.Retry || cfg.RateLimit != r.Provider.RateLimit || cfg.CircuitBreaker != r.Provider.CircuitBreaker
+	domainMapChanged := !reflect.DeepEqual(domainMap, r.Resolver.domainMap())
+	zoneNames := cfg.ZoneNames(domainMap)
+	zonesChanged := !slices.Equal(zoneNames, r.Provider.ZoneNames(r.Resolver.domainMap()))
+	if !providerChanged && !domainMapChanged && !zonesChanged {
+		r.Log.V(1).Info("configuration unchanged")
+		return nil
+	}
+	zones, err := dns.NewZones(zoneNames...)
+	if err != nil {
+		return err
+	}
+
+	// The provider is the only part that can still fail, so create it before
+	// swapping anything in.
+	if providerChanged {
+		provider, err := r.NewProvider(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		r.DNS.Swap(provider)
+		r.Log.Info("reloaded DNS provider config", "provider", cfg.Provider)
+	}
+	if zonesChanged {
+		r.DNS.SetZones(zones)
+		r.Log.Info("reloaded DNS zones", "zones", zones.Names())
+	}
+	applied := *r.Provider
+	applied.Provider, applied.Settings, applied.Zones = cfg.Provider, cfg.Settings, cfg.Zones
+	applied.Retry, applied.RateLimit, applied.CircuitBreaker = cfg.Retry, cfg.RateLimit, cfg.CircuitBreaker
+	r.Provider = &applied
+	if domainMapChanged {
+		r.Resolver.SetDomainMap(domainMap)
+		r.Log.Info("reloaded domain map", "path", r.DomainMapPath)
+	}
+
+	for _, route := range r.Routes {
+		if err := route.EnqueueAll(ctx); err != nil {
+			// The new configuration is in effect; the routes pick it up on their next change or resync.
+			r.Log.Error(err, "queueing routes after config reload", "kind", route.kind().Kind)
+		}
+	}
+	return nil
+}
+
+// loadDomainMap reads the domain map, which may be missing with the gateway
+// value source, like at startup.
+func (r *ConfigReloader) loadDomainMap() (*config.DomainMap, error) {
+	domainMap, err := config.LoadDomainMap(r.DomainMapPath)
+	if errors.Is(err, fs.ErrNotExist) && r.Provider.ValueSource == config.ValueSourceGateway {
+		return nil, nil
+	}
+	return domainMap, err
+}
+
+// needsRestart reports whether provider config options other than the
+// provider, its settings and middlewares, and the zones differ.
+func needsRestart(current, next *config.ProviderConfig) bool {
+	return !reflect.DeepEqual(restartOnly(*current), restartOnly(*next))
+}
+
+// restartOnly returns cfg without the options a reload applies.
+func restartOnly(cfg config.ProviderConfig) config.ProviderConfig {
+	cfg.Provider, cfg.Settings, cfg.Zones = "", nil, nil
+	cfg.Retry, cfg.RateLimit, cfg.CircuitBreaker = config.RetryConfig{}, config.RateLimitConfig{}, config.BreakerConfig{}
+	return cfg
+}
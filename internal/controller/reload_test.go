@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+const testProviderConfig = "provider: opnsense\nsettings:\n  base_url: https://fw1.lan/api\n"
+
+// newTestReloader writes the config files and returns a reloader whose current
+// configuration matches them.
+func newTestReloader(t *testing.T, mock *mockDNSProvider, routes ...*RouteReconciler) *ConfigReloader {
+	t.Helper()
+	dir := t.TempDir()
+	domainMapPath := filepath.Join(dir, "domain-map.yaml")
+	providerPath := filepath.Join(dir, "dns-provider.yaml")
+	writeTestFile(t, domainMapPath, "my-domain1.com: 10.0.8.100\n")
+	writeTestFile(t, providerPath, testProviderConfig)
+
+	cfg, err := config.LoadProviderConfigFromPath(providerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm, err := config.LoadDomainMap(domainMapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ConfigReloader{
+		Log:           zap.New(zap.UseDevMode(true)),
+		DomainMapPath: domainMapPath,
+		ProviderPath:  providerPath,
+		Provider:      cfg,
+		Resolver:      &Resolver{DomainMap: dm},
+		DNS:           dns.NewSwappable(mock),
+		NewProvider: func(context.Context, *config.ProviderConfig) (dns.Provider, error) {
+			return nil, errors.New("unexpected provider change")
+		},
+		Routes: routes,
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigReloader_SwapsDomainMapAndRequeues(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(managedRoute("route", "app.my-domain2.it")).
+		Build()
+	routes := &RouteReconciler{Client: fakeClient, requeue: make(chan event.GenericEvent, 1)}
+
+	reloader := newTestReloader(t, &mockDNSProvider{}, routes)
+	writeTestFile(t, reloader.DomainMapPath, "my-domain1.com: 10.0.8.100\nmy-domain2.it: 10.0.9.50\n")
+
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ip, ok := reloader.Resolver.domainMap().LookupIP("app.my-domain2.it"); !ok || ip != "10.0.9.50" {
+		t.Errorf("expected the reloaded domain map in the resolver, got %q, %v", ip, ok)
+	}
+	select {
+	case e := <-routes.requeue:
+		if e.Object.GetName() != "route" {
+			t.Errorf("expected route to be queued, got %s", e.Object.GetName())
+		}
+	default:
+		t.Error("expected routes to be queued after the reload")
+	}
+	if got := testutil.ToFloat64(configLastReloadSuccessful); got != 1 {
+		t.Errorf("expected last reload successful, got %v", got)
+	}
+}
+
+func TestConfigReloader_InvalidKeepsPrevious(t *testing.T) {
+	reloader := newTestReloader(t, &mockDNSProvider{})
+	previous := reloader.Resolver.domainMap()
+	failures := testutil.ToFloat64(configReloadsTotal.WithLabelValues(reloadFailed))
+
+	writeTestFile(t, reloader.DomainMapPath, "my-domain1.com: 10.0.8.300\n")
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Fatal("expected error for an invalid domain map")
+	}
+
+	if reloader.Resolver.domainMap() != previous {
+		t.Error("expected the previous domain map to stay in effect")
+	}
+	if got := testutil.ToFloat64(configReloadsTotal.WithLabelValues(reloadFailed)); got != failures+1 {
+		t.Errorf("expected the failure to be counted, got %v", got)
+	}
+	if got := testutil.ToFloat64(configLastReloadSuccessful); got != 0 {
+		t.Errorf("expected last reload unsuccessful, got %v", got)
+	}
+}
+
+func TestConfigReloader_SwapsProvider(t *testing.T) {
+	old, next := &mockDNSProvider{}, &mockDNSProvider{}
+	reloader := newTestReloader(t, old)
+	reloader.NewProvider = func(_ context.Context, cfg *config.ProviderConfig) (dns.Provider, error) {
+		if cfg.Settings["base_url"] == "https://unreachable.lan/api" {
+			return nil, errors.New("health check failed")
+		}
+		return next, nil
+	}
+
+	// A provider that fails its health check is rejected, together with the
+	// domain map change in the same reload.
+	writeTestFile(t, reloader.ProviderPath, "provider: opnsense\nsettings:\n  base_url: https://unreachable.lan/api\n")
+	writeTestFile(t, reloader.DomainMapPath, "my-domain2.it: 10.0.9.50\n")
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Fatal("expected error for an unhealthy provider")
+	}
+	if reloader.DNS.Current() != old {
+		t.Error("expected the previous provider to stay in effect")
+	}
+	if _, ok := reloader.Resolver.domainMap().LookupIP("app.my-domain1.com"); !ok {
+		t.Error("expected the previous domain map to stay in effect")
+	}
+
+	writeTestFile(t, reloader.ProviderPath, "provider: opnsense\nsettings:\n  base_url: https://fw2.lan/api\n")
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reloader.DNS.Current() != next {
+		t.Error("expected the new provider to be swapped in")
+	}
+	if reloader.Provider.Settings["base_url"] != "https://fw2.lan/api" {
+		t.Errorf("expected the applied settings to be recorded, got %v", reloader.Provider.Settings)
+	}
+}
+
+func TestConfigReloader_ReappliesMiddlewares(t *testing.T) {
+	old, next := &mockDNSProvider{}, &mockDNSProvider{}
+	reloader := newTestReloader(t, old)
+	var created *config.ProviderConfig
+	reloader.NewProvider = func(_ context.Context, cfg *config.ProviderConfig) (dns.Provider, error) {
+		created = cfg
+		return next, nil
+	}
+
+	writeTestFile(t, reloader.ProviderPath, testProviderConfig+"retry:\n  attempts: 3\nrate_limit:\n  writes_per_second: 5\n")
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reloader.DNS.Current() != next || created == nil || created.Retry.Attempts != 3 || created.RateLimit.WritesPerSecond != 5 {
+		t.Fatal("expected the provider to be recreated with the changed retry and rate limit")
+	}
+	if reloader.Provider.Retry != created.Retry || reloader.Provider.RateLimit != created.RateLimit {
+		t.Errorf("expected the applied middlewares to be recorded, got %+v", reloader.Provider)
+	}
+	if needsRestart(reloader.Provider, created) {
+		t.Error("expected middleware changes not to need a restart")
+	}
+}
+
+func TestConfigReloader_SetsZones(t *testing.T) {
+	old, next := &mockDNSProvider{}, &mockDNSProvider{}
+	reloader := newTestReloader(t, old)
+	reloader.NewProvider = func(context.Context, *config.ProviderConfig) (dns.Provider, error) {
+		return next, nil
+	}
+
+	// Zones inferred from the domain map follow its changes.
+	writeTestFile(t, reloader.DomainMapPath, "\"*.my-domain1.com\": 10.0.8.100\n\"*.lab.my-domain2.it\": 10.0.9.50\n")
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := old.zones.Names(); !slices.Equal(got, []string{"lab.my-domain2.it", "my-domain1.com"}) {
+		t.Errorf("expected zones inferred from the domain map, got %v", got)
+	}
+
+	// Configured zones win, and a provider swapped in gets them too.
+	writeTestFile(t, reloader.ProviderPath, "provider: opnsense\nzones: [my-domain2.it]\nsettings:\n  base_url: https://fw2.lan/api\n")
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := next.zones.Names(); !slices.Equal(got, []string{"my-domain2.it"}) {
+		t.Errorf("expected the configured zones on the new provider, got %v", got)
+	}
+}
+
+func TestConfigReloader_WatchesFiles(t *testing.T) {
+	reloader := newTestReloader(t, &mockDNSProvider{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- reloader.Start(ctx) }()
+
+	// Give the watcher time to start, then update the file the way a rewrite does.
+	time.Sleep(100 * time.Millisecond)
+	writeTestFile(t, reloader.DomainMapPath, "my-domain2.it: 10.0.9.50\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := reloader.Resolver.domainMap().LookupIP("app.my-domain2.it"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("domain map was not reloaded after the file changed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
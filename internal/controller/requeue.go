@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Failed reconciles are handled by the class the DNS provider gave the error:
+//
+//   - dns.ErrNonRetryable: the DNS server rejected the records as invalid and
+//     would reject them again. The failure is reported in an event and the
+//     status, and the object is not requeued until it or the config changes.
+//   - dns.ErrAuthFailed: the DNS server rejected the credentials. The
+//     controller reports itself not ready until they work again, see
+//     ProviderHealth, and the object is requeued like a retryable error.
+//   - anything else, including dns.ErrRetryable: the object is requeued with
+//     exponential backoff, from requeueBaseDelay up to requeueMaxDelay.
+
+const (
+	requeueBaseDelay = time.Second
+	requeueMaxDelay  = 5 * time.Minute
+)
+
+// newRateLimiter returns the rate limiter spacing out the requeues of objects
+// whose reconcile failed.
+func newRateLimiter() workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](requeueBaseDelay, requeueMaxDelay)
+}
+
+// requeueError returns the error a reconciler returns for err: a terminal
+// error, which is not requeued, for non-retryable errors, otherwise err.
+func requeueError(err error) error {
+	if errors.Is(err, dns.ErrNonRetryable) {
+		return reconcile.TerminalError(err)
+	}
+	return err
+}
+
+// failureReason returns the reason of the Warning event reporting err.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, dns.ErrNonRetryable):
+		return reasonRejected
+	case errors.Is(err, dns.ErrAuthFailed):
+		return reasonAuthFailed
+	default:
+		return reasonFailed
+	}
+}
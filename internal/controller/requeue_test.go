@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+func TestRouteReconciler_NonRetryableError(t *testing.T) {
+	route := newSharedRoute("web", "app.my-domain1.com")
+	mock := &mockDNSProvider{applyErr: dns.Classify(errors.New("opnsense: addHostOverride unexpected result: failed"), dns.ErrNonRetryable)}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, fakeClient := newClaimsReconciler(t, mock, recorder, route)
+
+	rejected := testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("HTTPRoute", reasonRejected))
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	_, err := reconciler.Reconcile(context.Background(), req)
+	if !errors.Is(err, reconcile.TerminalError(nil)) {
+		t.Fatalf("expected a terminal error that is not requeued, got %v", err)
+	}
+	if got := testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("HTTPRoute", reasonRejected)); got != rejected+1 {
+		t.Errorf("expected the error to be counted as Rejected, got %v", got-rejected)
+	}
+
+	const msg = "Warning Rejected applying DNS changes: opnsense: addHostOverride unexpected result: failed"
+	if got := drainEvents(recorder); !slices.Equal(got, []string{msg}) {
+		t.Errorf("expected a Rejected event, got %q", got)
+	}
+	var updated gatewayv1.HTTPRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if s := readStatus(&updated)["app.my-domain1.com"]; s.State != stateFailed {
+		t.Errorf("expected the hostname to be Failed, got %+v", s)
+	}
+}
+
+func TestRouteReconciler_RetryableError(t *testing.T) {
+	route := newSharedRoute("web", "app.my-domain1.com")
+	mock := &mockDNSProvider{applyErr: dns.Classify(errors.New("opnsense: reconfigure returned status 503"), dns.ErrRetryable)}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, _ := newClaimsReconciler(t, mock, recorder, route)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	_, err := reconciler.Reconcile(context.Background(), req)
+	if err == nil || errors.Is(err, reconcile.TerminalError(nil)) {
+		t.Fatalf("expected an error that is requeued with backoff, got %v", err)
+	}
+	if got := drainEvents(recorder); !slices.Equal(got, []string{"Warning Failed applying DNS changes: opnsense: reconfigure returned status 503"}) {
+		t.Errorf("expected a Failed event, got %q", got)
+	}
+}
+
+func TestRouteReconciler_AuthFailedDegradesReadiness(t *testing.T) {
+	route := newSharedRoute("web", "app.my-domain1.com")
+	mock := &mockDNSProvider{applyErr: dns.Classify(errors.New("opnsense: addHostOverride returned status 401"), dns.ErrAuthFailed)}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, _ := newClaimsReconciler(t, mock, recorder, route)
+	health := &ProviderHealth{DNS: mock}
+	reconciler.Health = health
+
+	if err := health.Check(newProbeRequest()); err != nil {
+		t.Fatalf("expected ready before any failure, got %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	_, err := reconciler.Reconcile(context.Background(), req)
+	if !errors.Is(err, dns.ErrAuthFailed) || errors.Is(err, reconcile.TerminalError(nil)) {
+		t.Fatalf("expected an authentication error that is requeued, got %v", err)
+	}
+	if got := drainEvents(recorder); len(got) != 1 || got[0] != "Warning AuthFailed applying DNS changes: opnsense: addHostOverride returned status 401" {
+		t.Errorf("expected an AuthFailed event, got %q", got)
+	}
+	if err := health.Check(newProbeRequest()); !errors.Is(err, dns.ErrAuthFailed) {
+		t.Errorf("expected readiness to report the authentication failure, got %v", err)
+	}
+}
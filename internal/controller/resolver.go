@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
+)
+
+// Resolver decides which hostnames the controller manages and which value
+// their records point to. With the domain-map source every value comes from the
+// domain map. With the gateway source values come from load balancer status
+// addresses, those of the route's parent Gateways or an Ingress's own, and the
+// domain map acts as an override or, in allowlist mode, restricts which
+// hostnames are managed.
+type Resolver struct {
+	Reader    client.Reader
+	DomainMap *config.DomainMap // replace with SetDomainMap once the controllers run
+	Source    string            // config.ValueSourceDomainMap or config.ValueSourceGateway
+	Allowlist bool              // gateway source only: manage only hostnames in the domain map
+
+	mu sync.RWMutex // guards DomainMap
+}
+
+// SetDomainMap replaces the domain map, e.g. after the file was reloaded.
+func (v *Resolver) SetDomainMap(dm *config.DomainMap) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.DomainMap = dm
+}
+
+// domainMap returns the current domain map, which may be nil.
+func (v *Resolver) domainMap() *config.DomainMap {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.DomainMap
+}
+
+// gatewaySource reports whether values are taken from Gateway status addresses.
+func (v *Resolver) gatewaySource() bool {
+	return v.Source == config.ValueSourceGateway
+}
+
+// lookup returns the domain map addresses for a hostname. A missing domain map
+// matches nothing.
+func (v *Resolver) lookup(hostname string) (config.Entry, bool) {
+	dm := v.domainMap()
+	if dm == nil {
+		return config.Entry{}, false
+	}
+	return dm.Lookup(hostname)
+}
+
+// Manages reports whether records for the hostname of an object of the given
+// kind are managed at all, independently of whether a value can currently be
+// resolved.
+func (v *Resolver) Manages(kind RouteKind, hostname string) bool {
+	if kind.statusValues || (v.gatewaySource() && !v.Allowlist) {
+		return true
+	}
+	_, ok := v.lookup(hostname)
+	return ok
+}
+
+// target is a record type and value resolved for a hostname. TTL and
+// Description come from the matching domain map entry and only affect how the
+// record is written, so they are left out of the route status.
+type target struct {
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+	TTL         int    `json:"-"`
+	Description string `json:"-"`
+}
+
+// Resolve returns the records a hostname of an object of the given kind should
+// have. It returns none when the hostname is not managed or no value is
+// available yet, e.g. because the parent Gateway has no address assigned.
+func (v *Resolver) Resolve(ctx context.Context, kind RouteKind, obj client.Object, hostname string) ([]target, error) {
+	if kind.statusValues {
+		return kind.ownAddresses(obj).targets(), nil
+	}
+
+	mapped, inMap := v.lookup(hostname)
+	targets, err := v.resolve(ctx, kind, obj, mapped, inMap)
+	if err != nil || !inMap || len(targets) == 0 {
+		return targets, err
+	}
+
+	// The entry shapes the records even when the values come from a Gateway.
+	description, err := mapped.RenderDescription(config.DescriptionData{
+		Hostname:  hostname,
+		Kind:      kind.Kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rendering description for %s: %w", hostname, err)
+	}
+	for i := range targets {
+		targets[i].TTL = mapped.TTL
+		targets[i].Description = description
+	}
+	return targets, nil
+}
+
+// resolve returns the targets for a hostname given its domain map entry, if any.
+func (v *Resolver) resolve(ctx context.Context, kind RouteKind, obj client.Object, mapped config.Entry, inMap bool) ([]target, error) {
+	if !v.gatewaySource() {
+		if !inMap {
+			return nil, nil
+		}
+		return mappedTargets(mapped), nil
+	}
+	if v.Allowlist && !inMap {
+		return nil, nil
+	}
+	if inMap && !v.Allowlist {
+		return mappedTargets(mapped), nil
+	}
+
+	addrs, err := kind.statusAddresses(ctx, v, obj)
+	if err != nil {
+		return nil, err
+	}
+	return addrs.targets(), nil
+}
+
+// recordTypes returns the record types managed for hostnames of the given
+// kind. AAAA and CNAME records are only managed when such a value can come up
+// at all, so IPv4-only setups don't look up or delete other record types.
+// Status addresses may hold either, so both are managed for them.
+func (v *Resolver) recordTypes(kind RouteKind) []string {
+	dm := v.domainMap()
+	statusAddrs := kind.statusValues || v.gatewaySource()
+	types := []string{"A"}
+	if statusAddrs || (dm != nil && dm.HasIPv6()) {
+		types = append(types, "AAAA")
+	}
+	if statusAddrs || (dm != nil && dm.HasCNAME()) {
+		types = append(types, "CNAME")
+	}
+	return types
+}
+
+// mappedTargets returns the records for a domain map entry: a CNAME record for
+// entries with a CNAME target, otherwise an A record for each IPv4 and an AAAA
+// record for each IPv6 address.
+func mappedTargets(entry config.Entry) []target {
+	if entry.CNAME != "" {
+		return []target{{Type: "CNAME", Value: entry.CNAME}}
+	}
+	var targets []target
+	for _, ip := range entry.IPv4 {
+		targets = append(targets, target{Type: "A", Value: ip})
+	}
+	for _, ip := range entry.IPv6 {
+		targets = append(targets, target{Type: "AAAA", Value: ip})
+	}
+	return targets
+}
+
+// statusAddresses holds load balancer status addresses, grouped by kind and in
+// the order they are reported.
+type statusAddresses struct {
+	IPv4      []string
+	IPv6      []string
+	Hostnames []string
+}
+
+// targets returns a target for every IPv4 and IPv6 address, which make up one
+// A and one AAAA record holding all addresses of that family. Without IP
+// addresses it returns a CNAME record for the first hostname, as reported by
+// cloud load balancers.
+func (a statusAddresses) targets() []target {
+	var targets []target
+	for _, ip := range a.IPv4 {
+		targets = append(targets, target{Type: "A", Value: ip})
+	}
+	for _, ip := range a.IPv6 {
+		targets = append(targets, target{Type: "AAAA", Value: ip})
+	}
+	if len(targets) == 0 && len(a.Hostnames) > 0 {
+		targets = append(targets, target{Type: "CNAME", Value: a.Hostnames[0]})
+	}
+	return targets
+}
+
+// addIP adds an IP address to the matching family, ignoring invalid and
+// duplicate addresses.
+func (a *statusAddresses) addIP(value string) {
+	ip := net.ParseIP(value)
+	switch {
+	case ip == nil:
+	case ip.To4() != nil:
+		if !Contains(a.IPv4, ip.String()) {
+			a.IPv4 = append(a.IPv4, ip.String())
+		}
+	default:
+		if !Contains(a.IPv6, ip.String()) {
+			a.IPv6 = append(a.IPv6, ip.String())
+		}
+	}
+}
+
+// gatewayAddresses collects the status addresses of the given Gateways.
+// Gateways that don't exist (yet) are skipped.
+func (v *Resolver) gatewayAddresses(ctx context.Context, gateways []types.NamespacedName) (statusAddresses, error) {
+	var addrs statusAddresses
+	for _, key := range gateways {
+		var gw gatewayv1.Gateway
+		if err := v.Reader.Get(ctx, key, &gw); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return addrs, fmt.Errorf("getting Gateway %s: %w", key, err)
+		}
+		for _, a := range gw.Status.Addresses {
+			if a.Type != nil && *a.Type == gatewayv1.HostnameAddressType {
+				if !Contains(addrs.Hostnames, a.Value) {
+					addrs.Hostnames = append(addrs.Hostnames, a.Value)
+				}
+				continue
+			}
+			if a.Type != nil && *a.Type != gatewayv1.IPAddressType {
+				continue
+			}
+			addrs.addIP(a.Value)
+		}
+	}
+	return addrs, nil
+}
+
+// parentGateways returns the Gateways referenced by the parentRefs of a route
+// in the given namespace.
+func parentGateways(namespace string, refs []gatewayv1.ParentReference) []types.NamespacedName {
+	var keys []types.NamespacedName
+	for _, ref := range refs {
+		if ref.Group != nil && *ref.Group != gatewayv1.GroupName {
+			continue
+		}
+		if ref.Kind != nil && *ref.Kind != "Gateway" {
+			continue
+		}
+		ns := namespace
+		if ref.Namespace != nil {
+			ns = string(*ref.Namespace)
+		}
+		key := types.NamespacedName{Namespace: ns, Name: string(ref.Name)}
+		if !containsKey(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func containsKey(keys []types.NamespacedName, key types.NamespacedName) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
+)
+
+func newTestGateway(namespace, name string, addresses ...gatewayv1.GatewayStatusAddress) *gatewayv1.Gateway {
+	return &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "test"},
+		Status:     gatewayv1.GatewayStatus{Addresses: addresses},
+	}
+}
+
+func ipAddress(value string) gatewayv1.GatewayStatusAddress {
+	t := gatewayv1.IPAddressType
+	return gatewayv1.GatewayStatusAddress{Type: &t, Value: value}
+}
+
+func hostnameAddress(value string) gatewayv1.GatewayStatusAddress {
+	t := gatewayv1.HostnameAddressType
+	return gatewayv1.GatewayStatusAddress{Type: &t, Value: value}
+}
+
+func newGatewayClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+	if err := gatewayv1alpha2.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api v1alpha2 scheme: %v", err)
+	}
+	if err := networkingv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to install networking scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to install core scheme: %v", err)
+	}
+	builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...)
+	for _, kind := range []RouteKind{HTTPRouteKind, GRPCRouteKind, TLSRouteKind, IngressKind(""), ServiceKind} {
+		builder = builder.WithIndex(kind.newObject(), hostnameIndex, kind.indexHostnames)
+	}
+	return builder.Build()
+}
+
+func routeWithParent(gatewayNamespace, gatewayName string) *gatewayv1.HTTPRoute {
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	}
+	ref := gatewayv1.ParentReference{Name: gatewayv1.ObjectName(gatewayName)}
+	if gatewayNamespace != "" {
+		ns := gatewayv1.Namespace(gatewayNamespace)
+		ref.Namespace = &ns
+	}
+	route.Spec.ParentRefs = []gatewayv1.ParentReference{ref}
+	return route
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	gw := newTestGateway("infra", "public",
+		hostnameAddress("lb.example.net"),
+		ipAddress("fd00::1"),
+		ipAddress("192.168.1.10"),
+		ipAddress("192.168.1.11"),
+	)
+	named := newTestGateway("infra", "named", hostnameAddress("lb.example.net"))
+	reader := newGatewayClient(t, gw, named)
+	dm := newTestDomainMap(t)
+	route := routeWithParent("infra", "public")
+
+	tests := []struct {
+		name     string
+		resolver *Resolver
+		route    *gatewayv1.HTTPRoute
+		hostname string
+		want     []target
+	}{
+		{
+			name:     "domain map source",
+			resolver: &Resolver{Reader: reader, DomainMap: dm},
+			route:    route,
+			hostname: "app.my-domain1.com",
+			want:     []target{{Type: "A", Value: "10.0.8.100"}},
+		},
+		{
+			name:     "domain map source ignores gateway",
+			resolver: &Resolver{Reader: reader, DomainMap: dm},
+			route:    route,
+			hostname: "app.unknown.com",
+		},
+		{
+			name:     "gateway source uses all IPv4 and IPv6 status addresses",
+			resolver: &Resolver{Reader: reader, DomainMap: dm, Source: config.ValueSourceGateway},
+			route:    route,
+			hostname: "app.unknown.com",
+			want:     []target{{Type: "A", Value: "192.168.1.10"}, {Type: "A", Value: "192.168.1.11"}, {Type: "AAAA", Value: "fd00::1"}},
+		},
+		{
+			name:     "gateway source uses a hostname status address as CNAME",
+			resolver: &Resolver{Reader: reader, DomainMap: dm, Source: config.ValueSourceGateway},
+			route:    routeWithParent("infra", "named"),
+			hostname: "app.unknown.com",
+			want:     []target{{Type: "CNAME", Value: "lb.example.net"}},
+		},
+		{
+			name:     "gateway source with domain map override",
+			resolver: &Resolver{Reader: reader, DomainMap: dm, Source: config.ValueSourceGateway},
+			route:    route,
+			hostname: "app.my-domain1.com",
+			want:     []target{{Type: "A", Value: "10.0.8.100"}},
+		},
+		{
+			name:     "gateway source with allowlist uses gateway value",
+			resolver: &Resolver{Reader: reader, DomainMap: dm, Source: config.ValueSourceGateway, Allowlist: true},
+			route:    route,
+			hostname: "app.my-domain1.com",
+			want:     []target{{Type: "A", Value: "192.168.1.10"}, {Type: "A", Value: "192.168.1.11"}, {Type: "AAAA", Value: "fd00::1"}},
+		},
+		{
+			name:     "gateway source with allowlist skips unlisted hostnames",
+			resolver: &Resolver{Reader: reader, DomainMap: dm, Source: config.ValueSourceGateway, Allowlist: true},
+			route:    route,
+			hostname: "app.unknown.com",
+		},
+		{
+			name:     "gateway source without domain map and missing gateway",
+			resolver: &Resolver{Reader: reader, Source: config.ValueSourceGateway},
+			route:    routeWithParent("infra", "missing"),
+			hostname: "app.unknown.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := tt.resolver.Resolve(context.Background(), HTTPRouteKind, tt.route, tt.hostname)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(targets, tt.want) {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.hostname, targets, tt.want)
+			}
+		})
+	}
+}
+
+func TestParentGateways(t *testing.T) {
+	route := routeWithParent("", "public")
+	service := gatewayv1.Kind("Service")
+	route.Spec.ParentRefs = append(route.Spec.ParentRefs,
+		gatewayv1.ParentReference{Name: "public"}, // duplicate
+		gatewayv1.ParentReference{Name: "mesh", Kind: &service},
+	)
+
+	keys := parentGateways(route.Namespace, route.Spec.ParentRefs)
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 gateway, got %v", keys)
+	}
+	if keys[0].Namespace != "default" || keys[0].Name != "public" {
+		t.Errorf("expected default/public, got %s", keys[0])
+	}
+}
+
+func TestRouteReconciler_RoutesForGateway(t *testing.T) {
+	attached := routeWithParent("infra", "public")
+	other := routeWithParent("infra", "internal")
+	other.Name = "other"
+
+	c := newGatewayClient(t, attached, other)
+	reconciler := &RouteReconciler{Client: c, Resolver: &Resolver{Reader: c}}
+
+	requests := reconciler.routesForGateway(context.Background(), newTestGateway("infra", "public"))
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %v", requests)
+	}
+	if requests[0].Name != "web" {
+		t.Errorf("expected request for 'web', got %s", requests[0].NamespacedName)
+	}
+}
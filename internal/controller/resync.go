@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Drift actions used in logs and the drift corrections metric.
+const (
+	driftRecreated = "recreated"
+	driftUpdated   = "updated"
+	driftSkipped   = "skipped"
+)
+
+// DriftResyncer periodically compares the records desired by routes with
+// the records held by the DNS provider and repairs any drift, such as
+// overrides edited or deleted by hand on the DNS server.
+type DriftResyncer struct {
+	client.Client
+	Log      logr.Logger
+	Resolver *Resolver
+	DNS      dns.Provider
+	Upsert   bool        // when false, drifted values are reported but never overwritten
+	Cluster  string      // cluster name used in record owner IDs
+	Kinds    []RouteKind // route kinds to collect hostnames from, defaults to HTTPRoute
+	Interval time.Duration
+	// Zones returns the zones managed records are counted in, optional.
+	Zones func() dns.Zones
+}
+
+// Start runs the resync loop until the context is cancelled.
+func (d *DriftResyncer) Start(ctx context.Context) error {
+	d.Log.Info("starting drift resync", "interval", d.Interval)
+	runPeriodically(ctx, d.Log, d.Interval, d.Resync)
+	return nil
+}
+
+// NeedLeaderElection ensures only the leader repairs drift.
+func (d *DriftResyncer) NeedLeaderElection() bool {
+	return true
+}
+
+// Resync performs a single drift detection and correction pass.
+func (d *DriftResyncer) Resync(ctx context.Context) error {
+	actual, err := d.DNS.List(ctx, dns.ListFilter{})
+	if err != nil {
+		return fmt.Errorf("listing DNS records: %w", err)
+	}
+	actualRecords := make(map[string]dns.Record, len(actual))
+	owners := make(map[string]string, len(actual))
+	for _, rec := range actual {
+		key := recordKey(rec)
+		if prev, ok := actualRecords[key]; ok {
+			rec.Values = slices.Concat(prev.Values, rec.Values)
+		}
+		actualRecords[key] = rec
+		owners[claimKey(rec.Hostname)] = rec.Owner()
+	}
+	countManaged(actual, d.Cluster, d.Zones)
+
+	desired, err := d.desiredRecords(ctx, owners)
+	if err != nil {
+		return err
+	}
+
+	var changes dns.ChangeSet
+	for _, rec := range desired {
+		got, ok := actualRecords[recordKey(rec)]
+		switch {
+		case !ok:
+			changes.Creates = append(changes.Creates, rec)
+		case dns.EqualValues(got.Values, rec.Values) && dns.EqualTTL(rec.TTL, got.TTL):
+			// In sync.
+		case d.Upsert:
+			changes.Updates = append(changes.Updates, rec)
+		default:
+			d.Log.Info("drift detected, leaving record unchanged because upsert is disabled",
+				"hostname", rec.Hostname, "type", rec.Type, "want", rec.Values, "got", got.Values,
+				"wantTTL", rec.TTL, "gotTTL", got.TTL, "action", driftSkipped)
+			driftCorrectionsTotal.WithLabelValues(driftSkipped).Inc()
+		}
+	}
+
+	if changes.IsEmpty() {
+		d.Log.V(1).Info("no drift detected", "records", len(desired))
+		lastSyncTimestamp.SetToCurrentTime()
+		return nil
+	}
+	err = d.DNS.ApplyChanges(ctx, changes)
+	if err != nil {
+		if !errors.Is(err, dns.ErrNotOwned) {
+			return fmt.Errorf("correcting drift: %w", err)
+		}
+		d.Log.Error(err, "drift detected on records owned by someone else, leaving them unchanged")
+	}
+
+	refused := refusedRecords(err)
+	for _, rec := range changes.Creates {
+		if refused[recordKey(rec)] != nil {
+			continue
+		}
+		d.Log.Info("drift corrected: record was missing", "hostname", rec.Hostname, "type", rec.Type, "values", rec.Values, "action", driftRecreated)
+		driftCorrectionsTotal.WithLabelValues(driftRecreated).Inc()
+	}
+	for _, rec := range changes.Updates {
+		if refused[recordKey(rec)] != nil {
+			continue
+		}
+		d.Log.Info("drift corrected: record values or TTL differed", "hostname", rec.Hostname, "type", rec.Type, "values", rec.Values, "ttl", rec.TTL, "action", driftUpdated)
+		driftCorrectionsTotal.WithLabelValues(driftUpdated).Inc()
+	}
+	lastSyncTimestamp.SetToCurrentTime()
+	return nil
+}
+
+// desiredRecords collects the records expected for all managed routes,
+// sorted by hostname and type. When several routes claim a hostname, the one
+// whose owner ID is in owners, the current owners by hostname, owns all of its
+// records, or else the first one listed.
+func (d *DriftResyncer) desiredRecords(ctx context.Context, owners map[string]string) ([]dns.Record, error) {
+	byHostname := make(map[string][]dns.Record)
+	for _, kind := range orDefaultKinds(d.Kinds) {
+		routes := kind.newList()
+		if err := d.List(ctx, routes); err != nil {
+			return nil, fmt.Errorf("listing %ss: %w", kind.Kind, err)
+		}
+
+		for _, route := range kind.items(routes) {
+			// Routes without our finalizer have not been reconciled yet, and routes
+			// being deleted are about to have their records removed.
+			if !route.GetDeletionTimestamp().IsZero() || !controllerutil.ContainsFinalizer(route, finalizerName) {
+				continue
+			}
+			owner := dns.OwnerID(d.Cluster, kind.Kind, route.GetNamespace(), route.GetName())
+			for _, h := range kind.hostnames(route) {
+				hostname := strings.TrimSuffix(h, ".")
+				targets, err := d.Resolver.Resolve(ctx, kind, route, hostname)
+				if err != nil {
+					return nil, fmt.Errorf("resolving value for %s: %w", hostname, err)
+				}
+				targets, _ = publishable(d.DNS, hostname, targets)
+				key := claimKey(hostname)
+				if len(targets) == 0 {
+					continue
+				}
+				if current := byHostname[key]; current != nil && (ownedBy(owners[key], current[0].Owner()) || !ownedBy(owners[key], owner)) {
+					continue
+				}
+				byHostname[key] = recordsFor(hostname, targets, owner)
+			}
+		}
+	}
+
+	var records []dns.Record
+	for _, recs := range byHostname {
+		records = append(records, recs...)
+	}
+	sort.Slice(records, func(i, j int) bool { return recordKey(records[i]) < recordKey(records[j]) })
+	return records, nil
+}
+
+// recordKey identifies a record by hostname and type, ignoring case and a trailing dot.
+func recordKey(rec dns.Record) string {
+	return strings.ToLower(strings.TrimSuffix(rec.Hostname, ".")) + "|" + strings.ToUpper(rec.Type)
+}
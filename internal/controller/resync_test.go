@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+func newResyncer(t *testing.T, mock *mockDNSProvider, upsert bool, routes ...*gatewayv1.HTTPRoute) *DriftResyncer {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, route := range routes {
+		builder = builder.WithObjects(route)
+	}
+
+	return &DriftResyncer{
+		Client:   builder.Build(),
+		Log:      zap.New(zap.UseDevMode(true)),
+		Resolver: newTestResolver(t),
+		DNS:      mock,
+		Upsert:   upsert,
+		Cluster:  "prod",
+		Interval: time.Minute,
+	}
+}
+
+func managedRoute(name string, hostnames ...gatewayv1.Hostname) *gatewayv1.HTTPRoute {
+	return &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: hostnames,
+		},
+	}
+}
+
+func TestDriftResyncer_RecreatesMissing(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}},
+		},
+	}
+	resyncer := newResyncer(t, mock, false,
+		managedRoute("route", "app.my-domain1.com", "api.my-domain1.com", "app.unknown.com"))
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected 1 recreated record, got %d", len(mock.createdRecords))
+	}
+	if mock.createdRecords[0].Hostname != "api.my-domain1.com" {
+		t.Errorf("expected 'api.my-domain1.com' to be recreated, got %q", mock.createdRecords[0].Hostname)
+	}
+}
+
+func TestDriftResyncer_UpdatesDriftedValueWithUpsert(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"192.168.1.1"}, Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/route"}},
+		},
+	}
+	resyncer := newResyncer(t, mock, true, managedRoute("route", "app.my-domain1.com"))
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 1 {
+		t.Fatalf("expected 1 updated record, got %d", len(mock.updatedRecords))
+	}
+	if !slices.Equal(mock.updatedRecords[0].Values, []string{"10.0.8.100"}) {
+		t.Errorf("expected value '10.0.8.100', got %q", mock.updatedRecords[0].Values)
+	}
+}
+
+func TestDriftResyncer_UpdatesDriftedTTL(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.volatile.com", Type: "A", Values: []string{"10.0.8.100"}, TTL: 300, Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/route"}},
+			{Hostname: "api.volatile.com", Type: "A", Values: []string{"10.0.8.100"}, TTL: 30, Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/route"}},
+		},
+	}
+	resyncer := newResyncer(t, mock, true, managedRoute("route", "app.volatile.com", "api.volatile.com"))
+	resyncer.Resolver = &Resolver{DomainMap: loadTestDomainMap(t, `
+version: 2
+domains:
+  volatile.com:
+    values: [10.0.8.100]
+    ttl: 30
+`)}
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 1 {
+		t.Fatalf("expected only the record with a drifted TTL to be updated, got %d", len(mock.updatedRecords))
+	}
+	if rec := mock.updatedRecords[0]; rec.Hostname != "app.volatile.com" || rec.TTL != 30 {
+		t.Errorf("expected app.volatile.com with TTL 30, got %s with TTL %d", rec.Hostname, rec.TTL)
+	}
+}
+
+func TestDriftResyncer_CreateOnlyNeverOverwrites(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"192.168.1.1"}},
+		},
+	}
+	resyncer := newResyncer(t, mock, false, managedRoute("route", "app.my-domain1.com"))
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 0 {
+		t.Errorf("expected no changes in create-only mode, got %v", mock.applied)
+	}
+}
+
+func TestDriftResyncer_LeavesForeignRecords(t *testing.T) {
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"192.168.1.1"}, Meta: map[string]string{"description": "my NAS"}},
+		},
+	}
+	resyncer := newResyncer(t, mock, true, managedRoute("route", "app.my-domain1.com"))
+	updated := testutil.ToFloat64(driftCorrectionsTotal.WithLabelValues(driftUpdated))
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 0 {
+		t.Errorf("expected hand-made record to be left alone, got %v", mock.updatedRecords)
+	}
+	if got := testutil.ToFloat64(driftCorrectionsTotal.WithLabelValues(driftUpdated)); got != updated {
+		t.Errorf("expected the refused update not to be counted as corrected, got %v more", got-updated)
+	}
+}
+
+func TestDriftResyncer_IgnoresUnmanagedRoutes(t *testing.T) {
+	route := managedRoute("route", "app.my-domain1.com")
+	route.Finalizers = nil
+
+	mock := &mockDNSProvider{}
+	resyncer := newResyncer(t, mock, true, route)
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 0 {
+		t.Errorf("expected no changes for route without finalizer, got %v", mock.applied)
+	}
+}
+
+func TestDriftResyncer_CollectsAllRouteKinds(t *testing.T) {
+	grpcRoute := &gatewayv1.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "grpc",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.GRPCRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"grpc.my-domain1.com"},
+		},
+	}
+
+	mock := &mockDNSProvider{}
+	resyncer := newResyncer(t, mock, false)
+	resyncer.Client = newGatewayClient(t, managedRoute("route", "app.my-domain1.com"), grpcRoute)
+	resyncer.Kinds = []RouteKind{HTTPRouteKind, GRPCRouteKind}
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 2 {
+		t.Fatalf("expected 2 recreated records, got %v", mock.createdRecords)
+	}
+	if mock.createdRecords[0].Hostname != "app.my-domain1.com" || mock.createdRecords[1].Hostname != "grpc.my-domain1.com" {
+		t.Errorf("expected records for both route kinds, got %v", mock.createdRecords)
+	}
+}
+
+func TestDriftResyncer_ManagedRecordsMetric(t *testing.T) {
+	owned := map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/route"}
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}, Meta: owned},
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.101"}, Meta: owned},
+			{Hostname: "a.lab.my-domain1.com", Type: "AAAA", Values: []string{"fd00::1"}, Meta: owned},
+			{Hostname: "nas.my-domain1.com", Type: "A", Values: []string{"10.0.8.5"}},
+			{Hostname: "web.my-domain1.com", Type: "A", Values: []string{"10.0.8.6"}, Meta: map[string]string{dns.MetaOwner: "staging/default/web"}},
+		},
+	}
+	resyncer := newResyncer(t, mock, false)
+	zones, err := dns.NewZones("my-domain1.com", "lab.my-domain1.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resyncer.Zones = func() dns.Zones { return zones }
+	managedRecords.WithLabelValues("stale.example.com", "A").Set(3)
+
+	before := time.Now().Unix()
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Round-robin values count as one record, records of others aren't counted.
+	if got := testutil.ToFloat64(managedRecords.WithLabelValues("my-domain1.com", "A")); got != 1 {
+		t.Errorf("expected 1 managed A record in my-domain1.com, got %v", got)
+	}
+	if got := testutil.ToFloat64(managedRecords.WithLabelValues("lab.my-domain1.com", "AAAA")); got != 1 {
+		t.Errorf("expected 1 managed AAAA record in lab.my-domain1.com, got %v", got)
+	}
+	if n := testutil.CollectAndCount(managedRecords); n != 2 {
+		t.Errorf("expected series for the current zones only, got %d", n)
+	}
+	if got := testutil.ToFloat64(lastSyncTimestamp); got < float64(before) {
+		t.Errorf("expected the last sync timestamp to be set, got %v", got)
+	}
+}
@@ -0,0 +1,541 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"k8s.io/client-go/util/retry"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+const (
+	finalizerName              = "dns.yk/cleanup"
+	managedHostnamesAnnotation = "dns.yk/managed-hostnames"
+)
+
+// RouteReconciler reconciles Gateway API routes of a single kind, managing DNS
+// records for their spec.hostnames.
+type RouteReconciler struct {
+	client.Client
+	APIReader client.Reader
+	Log       logr.Logger
+	Resolver  *Resolver
+	DNS       dns.Provider
+	Upsert    bool                 // when true, update existing records; when false, only create missing ones
+	Cluster   string               // cluster name used in record owner IDs
+	Kind      RouteKind            // route kind to reconcile, defaults to HTTPRoute
+	Recorder  events.EventRecorder // optional, receives an event for each DNS outcome
+	// Kinds are the route kinds searched for other routes claiming a hostname,
+	// defaulting to Kind. Each needs the hostname index set up by its reconciler.
+	Kinds  []RouteKind
+	Health *ProviderHealth // optional, told about authentication failures
+
+	requeue chan event.GenericEvent // feeds EnqueueAll into the controller's queue
+}
+
+// kind returns the route kind handled by the reconciler.
+func (r *RouteReconciler) kind() RouteKind {
+	if r.Kind.Kind == "" {
+		return HTTPRouteKind
+	}
+	return r.Kind
+}
+
+// Reconcile applies the DNS records of a route. Failures are requeued by
+// their class, see requeueError.
+func (r *RouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	result, err := r.reconcile(ctx, req)
+	if err != nil {
+		reconcileErrorsTotal.WithLabelValues(r.kind().Kind, failureReason(err)).Inc()
+	}
+	r.Health.Observe(err)
+	return result, requeueError(err)
+}
+
+func (r *RouteReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	kind := r.kind()
+	route := kind.newObject()
+	if err := r.APIReader.Get(ctx, req.NamespacedName, route); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Objects that are no longer selected, e.g. an Ingress moved to another
+	// class, stay managed until deleted so their records are cleaned up.
+	if !kind.selected(route) && !controllerutil.ContainsFinalizer(route, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+
+	currentHostnames := kind.hostnames(route)
+
+	specHostnames := make([]string, 0, len(currentHostnames))
+	for _, h := range currentHostnames {
+		if r.Resolver.Manages(kind, h) {
+			specHostnames = append(specHostnames, h)
+		} else {
+			r.Log.V(1).Info("hostname not in domain map, skipping", "hostname", h)
+		}
+	}
+
+	// Objects we already manage are still processed, so records of removed
+	// hostnames are cleaned up and the finalizer can be released.
+	if len(specHostnames) == 0 && !controllerutil.ContainsFinalizer(route, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+
+	owner := dns.OwnerID(r.Cluster, kind.Kind, route.GetNamespace(), route.GetName())
+
+	if !route.GetDeletionTimestamp().IsZero() {
+		if controllerutil.ContainsFinalizer(route, finalizerName) {
+			r.Log.Info("deleting DNS records for "+kind.Kind, "name", req.NamespacedName)
+			var changes dns.ChangeSet
+			var handovers []dns.Record
+			for _, hostname := range specHostnames {
+				next, recs, err := r.handOver(ctx, kind, hostname, owner)
+				if err != nil {
+					r.event(route, corev1.EventTypeWarning, failureReason(err), "DeleteRecords", "deleting DNS records: %v", err)
+					return ctrl.Result{}, fmt.Errorf("deleting DNS records: %w", err)
+				}
+				if next != "" {
+					handovers = append(handovers, recs...)
+					continue
+				}
+				deletes, err := r.ownedDeletes(ctx, kind, hostname, owner)
+				if err != nil {
+					r.event(route, corev1.EventTypeWarning, failureReason(err), "DeleteRecords", "deleting DNS records: %v", err)
+					return ctrl.Result{}, fmt.Errorf("deleting DNS records: %w", err)
+				}
+				changes.Deletes = append(changes.Deletes, deletes...)
+			}
+			err := r.DNS.ApplyChanges(ctx, withHandovers(changes, handovers))
+			if err != nil {
+				if !errors.Is(err, dns.ErrNotOwned) {
+					r.event(route, corev1.EventTypeWarning, failureReason(err), "DeleteRecords", "deleting DNS records: %v", err)
+					return ctrl.Result{}, fmt.Errorf("deleting DNS records: %w", err)
+				}
+				// Records created by someone else are left in place and must not block deletion.
+				r.Log.Error(err, "left DNS records owned by someone else in place", "name", req.NamespacedName)
+			}
+			refused := refusedRecords(err)
+			for _, rec := range changes.Deletes {
+				if refused[recordKey(rec)] == nil {
+					r.Log.Info("deleted DNS record", "hostname", rec.Hostname, "type", rec.Type)
+				}
+			}
+			r.recordEvents(route, changes, refused)
+			r.handoverEvents(route, handovers, refused)
+
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				if err := r.APIReader.Get(ctx, req.NamespacedName, route); err != nil {
+					return err
+				}
+				controllerutil.RemoveFinalizer(route, finalizerName)
+				return r.Update(ctx, route)
+			})
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to remove finalizer: %w", err)
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(route, finalizerName) {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := r.APIReader.Get(ctx, req.NamespacedName, route); err != nil {
+				return err
+			}
+			controllerutil.AddFinalizer(route, finalizerName)
+			return r.Update(ctx, route)
+		})
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	var managedHostnames []string
+	if val, ok := route.GetAnnotations()[managedHostnamesAnnotation]; ok {
+		_ = json.Unmarshal([]byte(val), &managedHostnames)
+	}
+
+	managedHostnamesFiltered := make([]string, 0, len(managedHostnames))
+	for _, h := range managedHostnames {
+		if r.Resolver.Manages(kind, h) {
+			managedHostnamesFiltered = append(managedHostnamesFiltered, h)
+		}
+	}
+
+	// Statuses of hostnames that are no longer managed are dropped.
+	statuses := readStatus(route)
+	for h := range statuses {
+		if !Contains(specHostnames, h) {
+			delete(statuses, h)
+		}
+	}
+
+	var changes dns.ChangeSet
+	var handovers []dns.Record
+
+	// Delete hostnames that were removed from the spec, unless another route still claims them
+	for _, oldHost := range managedHostnamesFiltered {
+		if !Contains(currentHostnames, oldHost) {
+			next, recs, err := r.handOver(ctx, kind, oldHost, owner)
+			if err != nil {
+				r.event(route, corev1.EventTypeWarning, failureReason(err), "DeleteRecords", "%s: %v", oldHost, err)
+				return ctrl.Result{}, fmt.Errorf("handing over %s: %w", oldHost, err)
+			}
+			if next != "" {
+				handovers = append(handovers, recs...)
+				continue
+			}
+			r.Log.Info("hostname removed from "+kind.Kind+", deleting DNS record", "hostname", oldHost)
+			deletes, err := r.ownedDeletes(ctx, kind, oldHost, owner)
+			if err != nil {
+				r.event(route, corev1.EventTypeWarning, failureReason(err), "DeleteRecords", "%s: %v", oldHost, err)
+				return ctrl.Result{}, fmt.Errorf("deleting %s: %w", oldHost, err)
+			}
+			changes.Deletes = append(changes.Deletes, deletes...)
+		}
+	}
+
+	// Update and Create
+	for _, hostname := range specHostnames {
+		targets, err := r.Resolver.Resolve(ctx, kind, route, hostname)
+		if err != nil {
+			err = fmt.Errorf("resolving value for %s: %w", hostname, err)
+			return ctrl.Result{}, r.failed(ctx, req.NamespacedName, route, statuses, hostname, "ResolveRecord", err)
+		}
+		targets, unsupported := publishable(r.DNS, hostname, targets)
+		if len(unsupported) > 0 {
+			msg := unsupportedWildcardMessage(hostname, unsupported)
+			r.Log.Info("wildcard hostname not supported by the DNS provider, skipping", "hostname", hostname, "types", unsupported)
+			r.event(route, corev1.EventTypeWarning, reasonUnsupported, "ResolveRecord", "%s", msg)
+			if len(targets) == 0 {
+				statuses[hostname] = hostnameStatus{State: stateUnsupported, Error: msg}
+				continue
+			}
+		}
+		if len(targets) == 0 {
+			r.Log.Info("no address available for hostname yet, skipping", "hostname", hostname)
+			statuses[hostname] = hostnameStatus{State: statePending, Error: "no address available yet"}
+			continue
+		}
+		statuses[hostname] = hostnameStatus{State: stateReady, Records: targets}
+
+		for _, record := range recordsFor(hostname, targets, owner) {
+			r.Log.V(1).Info("resolved hostname", "hostname", hostname, "type", record.Type, "values", record.Values)
+
+			exists, err := r.DNS.Exists(ctx, hostname, record.Type)
+			if err != nil {
+				err = fmt.Errorf("checking DNS record for %s: %w", hostname, err)
+				return ctrl.Result{}, r.failed(ctx, req.NamespacedName, route, statuses, hostname, "CheckRecord", err)
+			}
+			if !exists {
+				changes.Creates = append(changes.Creates, record)
+				continue
+			}
+			existing, sharer, err := r.existingRecord(ctx, record, owner)
+			if err != nil {
+				err = fmt.Errorf("checking DNS record for %s: %w", hostname, err)
+				return ctrl.Result{}, r.failed(ctx, req.NamespacedName, route, statuses, hostname, "CheckRecord", err)
+			}
+			switch {
+			case sharer != nil && dns.EqualValues(record.Values, existing.Values):
+				r.Log.V(1).Info("hostname shared with another route, keeping its DNS record",
+					"hostname", hostname, "type", record.Type, "owner", existing.Owner())
+			case sharer != nil:
+				msg := conflictMessage(kind, record, *existing, *sharer)
+				r.Log.Info("conflicting claims for hostname, keeping the existing DNS record",
+					"hostname", hostname, "type", record.Type, "owner", existing.Owner(), "want", record.Values, "got", existing.Values)
+				r.event(route, corev1.EventTypeWarning, reasonConflict, "ClaimHostname", "%s", msg)
+				statuses[hostname] = hostnameStatus{State: stateConflict, Records: statuses[hostname].Records, Error: msg}
+				hostnameConflictsTotal.Inc()
+			case !r.Upsert:
+				// Non-upsert path: only create if missing
+				r.Log.V(1).Info("DNS record already exists, skipping", "hostname", hostname, "type", record.Type)
+			case existing != nil && dns.Unchanged(record, *existing):
+				r.Log.V(1).Info("DNS record unchanged, skipping update", "hostname", hostname, "type", record.Type)
+				unchangedRecordsTotal.Inc()
+			default:
+				changes.Updates = append(changes.Updates, record)
+			}
+		}
+
+		// Drop record types the hostname no longer resolves to, e.g. the AAAA
+		// record of a Service that lost its IPv6 address.
+		for _, recordType := range r.Resolver.recordTypes(kind) {
+			if containsType(targets, recordType) {
+				continue
+			}
+			exists, err := r.DNS.Exists(ctx, hostname, recordType)
+			if err != nil {
+				err = fmt.Errorf("checking DNS record for %s: %w", hostname, err)
+				return ctrl.Result{}, r.failed(ctx, req.NamespacedName, route, statuses, hostname, "CheckRecord", err)
+			}
+			if exists {
+				changes.Deletes = append(changes.Deletes, ownedKey(hostname, recordType, owner))
+			}
+		}
+	}
+
+	err := r.DNS.ApplyChanges(ctx, withHandovers(changes, handovers))
+	if err != nil && !errors.Is(err, dns.ErrNotOwned) {
+		err = fmt.Errorf("applying DNS changes: %w", err)
+		for _, rec := range slices.Concat(changes.Creates, changes.Updates, changes.Deletes) {
+			if _, ok := statuses[rec.Hostname]; ok {
+				statuses[rec.Hostname] = hostnameStatus{State: stateFailed, Records: statuses[rec.Hostname].Records, Error: err.Error()}
+			}
+		}
+		r.event(route, corev1.EventTypeWarning, failureReason(err), "ApplyRecords", "%v", err)
+		if statusErr := r.writeStatus(ctx, req.NamespacedName, route, statuses); statusErr != nil {
+			r.Log.Error(statusErr, "failed to record DNS status", "name", req.NamespacedName)
+		}
+		return ctrl.Result{}, err
+	}
+	refused := refusedRecords(err)
+	if err != nil {
+		// Retrying won't help until someone resolves the conflicting record by hand.
+		r.Log.Error(err, "skipped DNS records owned by someone else", "name", req.NamespacedName)
+		for _, e := range refused {
+			for _, hostname := range specHostnames {
+				if strings.EqualFold(strings.TrimSuffix(e.Hostname, "."), hostname) {
+					statuses[hostname] = hostnameStatus{State: stateFailed, Records: statuses[hostname].Records, Error: e.Error()}
+				}
+			}
+			r.event(route, corev1.EventTypeWarning, reasonFailed, "ApplyRecords", "%v", e)
+		}
+	}
+	r.recordEvents(route, changes, refused)
+	r.handoverEvents(route, handovers, refused)
+	for _, rec := range changes.Creates {
+		r.Log.Info("created DNS record", "hostname", rec.Hostname, "type", rec.Type, "values", rec.Values)
+	}
+	for _, rec := range changes.Updates {
+		r.Log.Info("updated DNS record", "hostname", rec.Hostname, "type", rec.Type, "values", rec.Values)
+	}
+
+	// Update annotations with the current list of managed hostnames and their status
+	statusChanged := setStatusAnnotation(route.DeepCopyObject().(client.Object), statuses)
+	if !slices.Equal(managedHostnames, currentHostnames) || statusChanged {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := r.APIReader.Get(ctx, req.NamespacedName, route); err != nil {
+				return err
+			}
+			annotations := route.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			data, _ := json.Marshal(currentHostnames)
+			annotations[managedHostnamesAnnotation] = string(data)
+			route.SetAnnotations(annotations)
+			setStatusAnnotation(route, statuses)
+			return r.Update(ctx, route)
+		})
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update managed-hostnames annotation: %w", err)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// newRecord builds the DNS record the controller manages for a hostname.
+func newRecord(hostname, recordType string, values []string, owner string) dns.Record {
+	return dns.Record{
+		Hostname: hostname,
+		Type:     recordType,
+		Values:   values,
+		Meta: map[string]string{
+			"description": dns.ManagedDescription,
+			dns.MetaOwner: owner,
+		},
+	}
+}
+
+// recordsFor builds the records for a hostname's resolved targets, one per
+// record type holding all values of that type, in the order the types appear.
+// TTL and description are taken from the first target of each type.
+func recordsFor(hostname string, targets []target, owner string) []dns.Record {
+	var records []dns.Record
+	for _, t := range targets {
+		i := slices.IndexFunc(records, func(rec dns.Record) bool { return rec.Type == t.Type })
+		if i < 0 {
+			rec := newRecord(hostname, t.Type, nil, owner)
+			rec.TTL = t.TTL
+			if t.Description != "" {
+				rec.Meta["description"] = t.Description
+			}
+			records = append(records, rec)
+			i = len(records) - 1
+		}
+		records[i].Values = append(records[i].Values, t.Value)
+	}
+	return records
+}
+
+// ownedDeletes returns the deletes for the records of hostname that owner may
+// remove, one per managed record type the provider holds. Records owned by
+// someone else are left in place.
+func (r *RouteReconciler) ownedDeletes(ctx context.Context, kind RouteKind, hostname, owner string) ([]dns.Record, error) {
+	existing, err := r.DNS.List(ctx, dns.ListFilter{Hostname: hostname})
+	if err != nil {
+		return nil, fmt.Errorf("listing DNS records for %s: %w", hostname, err)
+	}
+	types := r.Resolver.recordTypes(kind)
+	var deletes []dns.Record
+	for _, rec := range existing {
+		recordType := strings.ToUpper(rec.Type)
+		if !Contains(types, recordType) || slices.ContainsFunc(deletes, func(d dns.Record) bool { return d.Type == recordType }) {
+			continue
+		}
+		key := ownedKey(hostname, recordType, owner)
+		if dns.CheckOwner(key, rec) != nil {
+			r.Log.Info("leaving DNS record owned by someone else in place",
+				"hostname", hostname, "type", recordType, "owner", rec.Owner())
+			continue
+		}
+		deletes = append(deletes, key)
+	}
+	return deletes, nil
+}
+
+// ownedKey builds the record identifying a hostname to delete on behalf of owner.
+func ownedKey(hostname, recordType, owner string) dns.Record {
+	return dns.Record{
+		Hostname: hostname,
+		Type:     recordType,
+		Meta:     map[string]string{dns.MetaOwner: owner},
+	}
+}
+
+// containsType reports whether any target has the given record type.
+func containsType(targets []target, recordType string) bool {
+	for _, t := range targets {
+		if t.Type == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	kind := r.kind()
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named(strings.ToLower(kind.Kind)).
+		WithOptions(crcontroller.Options{RateLimiter: newRateLimiter()}).
+		For(kind.newObject(), builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// Reconcile if the Spec (Generation) has changed.
+				if e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() {
+					return true
+				}
+				// Also reconcile if finalizers have changed (e.g. our finalizer was added).
+				if len(e.ObjectOld.GetFinalizers()) != len(e.ObjectNew.GetFinalizers()) {
+					return true
+				}
+				// Kinds without a hostname field keep their hostnames in annotations.
+				if !slices.Equal(kind.hostnames(e.ObjectOld), kind.hostnames(e.ObjectNew)) {
+					return true
+				}
+				// Kinds that publish their own addresses change them through status.
+				if kind.ownAddresses != nil && (kind.statusValues || r.Resolver.gatewaySource()) {
+					return !reflect.DeepEqual(kind.ownAddresses(e.ObjectOld), kind.ownAddresses(e.ObjectNew))
+				}
+				// Ignore status-only updates.
+				return false
+			},
+		}))
+
+	if err := IndexHostnames(context.Background(), mgr.GetFieldIndexer(), kind); err != nil {
+		return err
+	}
+
+	r.requeue = make(chan event.GenericEvent)
+	b = b.WatchesRawSource(source.Channel(r.requeue, &handler.EnqueueRequestForObject{}))
+
+	if r.Resolver.gatewaySource() && kind.attachesToGateways() {
+		// Gateway addresses live in status, so watch status changes explicitly.
+		b = b.Watches(&gatewayv1.Gateway{},
+			handler.EnqueueRequestsFromMapFunc(r.routesForGateway),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldGw, okOld := e.ObjectOld.(*gatewayv1.Gateway)
+					newGw, okNew := e.ObjectNew.(*gatewayv1.Gateway)
+					if !okOld || !okNew {
+						return false
+					}
+					return !reflect.DeepEqual(oldGw.Status.Addresses, newGw.Status.Addresses)
+				},
+			}))
+	}
+
+	return b.Complete(r)
+}
+
+// EnqueueAll queues every selected object of the reconciled kind, so that a
+// changed configuration takes effect without waiting for the objects to change.
+// It does nothing before SetupWithManager.
+func (r *RouteReconciler) EnqueueAll(ctx context.Context) error {
+	if r.requeue == nil {
+		return nil
+	}
+	kind := r.kind()
+	list := kind.newList()
+	if err := r.List(ctx, list); err != nil {
+		return fmt.Errorf("listing %ss: %w", kind.Kind, err)
+	}
+	for _, obj := range kind.items(list) {
+		if !kind.selected(obj) && !controllerutil.ContainsFinalizer(obj, finalizerName) {
+			continue
+		}
+		select {
+		case r.requeue <- event.GenericEvent{Object: obj}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// routesForGateway maps a Gateway to the routes of the reconciled kind attached to it.
+func (r *RouteReconciler) routesForGateway(ctx context.Context, obj client.Object) []reconcile.Request {
+	kind := r.kind()
+	gw := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	routes := kind.newList()
+	if err := r.List(ctx, routes); err != nil {
+		r.Log.Error(err, "listing "+kind.Kind+"s for Gateway", "gateway", gw)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, route := range kind.items(routes) {
+		if containsKey(kind.parentGateways(route), gw) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: route.GetNamespace(), Name: route.GetName()},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,1308 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// mockDNSProvider records DNS operations for test assertions.
+type mockDNSProvider struct {
+	mu              sync.Mutex
+	existingHosts   map[string]bool // hostnames that Exists returns true for, for any type
+	listedRecords   []dns.Record    // records that List returns, and Exists when existingHosts is nil
+	createdRecords  []dns.Record
+	updatedRecords  []dns.Record
+	upsertedRecords []dns.Record
+	deletedHosts    []string
+	applied         []dns.ChangeSet
+	applyErr        error     // returned by ApplyChanges instead of applying when set
+	zones           dns.Zones // last zones set
+	wildcardTypes   []string  // record types SupportsWildcard reports as supported
+	healthErr       error     // returned by HealthCheck
+}
+
+func (m *mockDNSProvider) Exists(_ context.Context, hostname, recordType string) (bool, error) {
+	if m.existingHosts != nil {
+		return m.existingHosts[hostname], nil
+	}
+	for _, rec := range m.listedRecords {
+		if rec.Hostname == hostname && rec.Type == recordType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockDNSProvider) List(_ context.Context, filter dns.ListFilter) ([]dns.Record, error) {
+	var records []dns.Record
+	for _, rec := range m.listedRecords {
+		if filter.Matches(rec) {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+func (m *mockDNSProvider) Create(_ context.Context, record dns.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createdRecords = append(m.createdRecords, record)
+	return nil
+}
+
+func (m *mockDNSProvider) Update(_ context.Context, record dns.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updatedRecords = append(m.updatedRecords, record)
+	return nil
+}
+
+func (m *mockDNSProvider) Delete(_ context.Context, hostname, recordType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletedHosts = append(m.deletedHosts, hostname)
+	return nil
+}
+
+func (m *mockDNSProvider) Upsert(_ context.Context, record dns.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upsertedRecords = append(m.upsertedRecords, record)
+	return nil
+}
+
+func (m *mockDNSProvider) ApplyChanges(ctx context.Context, changes dns.ChangeSet) error {
+	m.mu.Lock()
+	m.applied = append(m.applied, changes)
+	m.mu.Unlock()
+	if m.applyErr != nil {
+		return m.applyErr
+	}
+	return dns.ApplySequentially(ctx, m, changes)
+}
+
+func (m *mockDNSProvider) HealthCheck(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthErr
+}
+
+func (m *mockDNSProvider) SetZones(zones dns.Zones) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.zones = zones
+}
+
+func (m *mockDNSProvider) SupportsWildcard(recordType string) bool {
+	return slices.Contains(m.wildcardTypes, recordType)
+}
+
+func newTestDomainMap(t *testing.T) *config.DomainMap {
+	t.Helper()
+	return loadTestDomainMap(t, "my-domain1.com: 10.0.8.100\nmy-domain2.it: 10.0.9.50\n")
+}
+
+// loadTestDomainMap loads a domain map from YAML content.
+func loadTestDomainMap(t *testing.T, content string) *config.DomainMap {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "domain-map.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dm, err := config.LoadDomainMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dm
+}
+
+func newTestResolver(t *testing.T) *Resolver {
+	t.Helper()
+	return &Resolver{DomainMap: newTestDomainMap(t)}
+}
+
+func TestRouteReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "default",
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "test-route",
+			Namespace: "default",
+		},
+	}
+
+	// First reconcile adds the finalizer
+	result, err := reconciler.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Requeue {
+		t.Error("expected no requeue")
+	}
+
+	// Second reconcile processes the hostnames
+	result, err = reconciler.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error on second reconcile: %v", err)
+	}
+	if result.Requeue {
+		t.Error("expected no requeue")
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected 1 created record, got %d", len(mock.createdRecords))
+	}
+	rec := mock.createdRecords[0]
+	if rec.Hostname != "app.my-domain1.com" {
+		t.Errorf("expected hostname 'app.my-domain1.com', got %q", rec.Hostname)
+	}
+	if rec.Owner() != "prod/HTTPRoute/default/test-route" {
+		t.Errorf("expected owner 'prod/HTTPRoute/default/test-route', got %q", rec.Owner())
+	}
+	if !slices.Equal(rec.Values, []string{"10.0.8.100"}) {
+		t.Errorf("expected value '10.0.8.100', got %q", rec.Values)
+	}
+	if rec.Type != "A" {
+		t.Errorf("expected type 'A', got %q", rec.Type)
+	}
+}
+
+func TestRouteReconciler_ReconcileUnknownDomain(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unknown-route",
+			Namespace: "default",
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.unknown.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "unknown-route",
+			Namespace: "default",
+		},
+	}
+
+	// First reconcile adds the finalizer
+	result, err := reconciler.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Second reconcile processes hostnames — no match expected
+	result, err = reconciler.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Requeue {
+		t.Error("expected no requeue")
+	}
+
+	if len(mock.createdRecords) != 0 {
+		t.Errorf("expected 0 created records for unknown domain, got %d", len(mock.createdRecords))
+	}
+}
+
+func TestRouteReconciler_UpsertEnabled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "upsert-route",
+			Namespace: "default",
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{
+		existingHosts: map[string]bool{"app.my-domain1.com": true},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Upsert:    true,
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "upsert-route",
+			Namespace: "default",
+		},
+	}
+
+	// First reconcile adds the finalizer
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Second reconcile updates the existing record
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 1 {
+		t.Fatalf("expected 1 updated record, got %d", len(mock.updatedRecords))
+	}
+	if len(mock.createdRecords) != 0 {
+		t.Errorf("expected 0 created records for existing host, got %d", len(mock.createdRecords))
+	}
+}
+
+func TestRouteReconciler_UpsertSkipsUnchanged(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(managedRoute("web", "app.my-domain1.com", "api.my-domain1.com")).
+		Build()
+
+	meta := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/HTTPRoute/default/web"}
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}, Meta: meta},
+			{Hostname: "api.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}, TTL: 300,
+				Meta: map[string]string{"description": "edited by hand", dns.MetaOwner: "prod/HTTPRoute/default/web"}},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Upsert:    true,
+		Cluster:   "prod",
+	}
+	skipped := testutil.ToFloat64(unchangedRecordsTotal)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.updatedRecords) != 1 {
+		t.Fatalf("expected only the changed record to be updated, got %d", len(mock.updatedRecords))
+	}
+	if mock.updatedRecords[0].Hostname != "api.my-domain1.com" {
+		t.Errorf("expected api.my-domain1.com to be updated, got %q", mock.updatedRecords[0].Hostname)
+	}
+	if got := testutil.ToFloat64(unchangedRecordsTotal); got != skipped+1 {
+		t.Errorf("expected 1 unchanged record to be counted, got %v", got-skipped)
+	}
+}
+
+func TestRouteReconciler_CreateSkipsExisting(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "skip-route",
+			Namespace: "default",
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{
+		existingHosts: map[string]bool{"app.my-domain1.com": true},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Upsert:    false,
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "skip-route",
+			Namespace: "default",
+		},
+	}
+
+	// First reconcile adds the finalizer
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Second reconcile skips existing record
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 0 {
+		t.Errorf("expected 0 created records for existing host, got %d", len(mock.createdRecords))
+	}
+}
+
+func TestRouteReconciler_Deletion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	now := metav1.Now()
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "delete-route",
+			Namespace:         "default",
+			Finalizers:        []string{finalizerName},
+			DeletionTimestamp: &now,
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com", "api.my-domain2.it"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	owned := map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/delete-route"}
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}, Meta: owned},
+			{Hostname: "api.my-domain2.it", Type: "A", Values: []string{"10.0.9.50"}, Meta: owned},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "delete-route",
+			Namespace: "default",
+		},
+	}
+
+	result, err := reconciler.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Requeue {
+		t.Error("expected no requeue")
+	}
+
+	if len(mock.deletedHosts) != 2 {
+		t.Fatalf("expected 2 deleted hosts, got %d", len(mock.deletedHosts))
+	}
+	if mock.deletedHosts[0] != "app.my-domain1.com" {
+		t.Errorf("expected first deleted host 'app.my-domain1.com', got %q", mock.deletedHosts[0])
+	}
+	if mock.deletedHosts[1] != "api.my-domain2.it" {
+		t.Errorf("expected second deleted host 'api.my-domain2.it', got %q", mock.deletedHosts[1])
+	}
+}
+
+func TestRouteReconciler_BatchesChanges(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "batch-route",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+			Annotations: map[string]string{
+				managedHostnamesAnnotation: `["old.my-domain1.com"]`,
+			},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com", "api.my-domain1.com", "web.my-domain2.it"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{
+		existingHosts: map[string]bool{"api.my-domain1.com": true},
+		listedRecords: []dns.Record{
+			{Hostname: "old.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"},
+				Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/batch-route"}},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Upsert:    true,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "batch-route",
+			Namespace: "default",
+		},
+	}
+
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 1 {
+		t.Fatalf("expected 1 ApplyChanges call, got %d", len(mock.applied))
+	}
+	changes := mock.applied[0]
+	if len(changes.Creates) != 2 {
+		t.Errorf("expected 2 creates, got %d", len(changes.Creates))
+	}
+	if len(changes.Updates) != 1 || changes.Updates[0].Hostname != "api.my-domain1.com" {
+		t.Errorf("expected 1 update for api.my-domain1.com, got %v", changes.Updates)
+	}
+	if len(changes.Deletes) != 1 || changes.Deletes[0].Hostname != "old.my-domain1.com" {
+		t.Errorf("expected 1 delete for old.my-domain1.com, got %v", changes.Deletes)
+	}
+}
+
+func TestRouteReconciler_DeletionLeavesForeignRecords(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	now := metav1.Now()
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "delete-route",
+			Namespace:         "default",
+			Finalizers:        []string{finalizerName},
+			DeletionTimestamp: &now,
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com", "nas.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/delete-route"}},
+			{Hostname: "nas.my-domain1.com", Type: "A", Meta: map[string]string{"description": "my NAS"}},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "delete-route",
+			Namespace: "default",
+		},
+	}
+
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.deletedHosts) != 1 || mock.deletedHosts[0] != "app.my-domain1.com" {
+		t.Fatalf("expected only 'app.my-domain1.com' to be deleted, got %v", mock.deletedHosts)
+	}
+
+	var updated gatewayv1.HTTPRoute
+	err := fakeClient.Get(context.Background(), req.NamespacedName, &updated)
+	if err == nil && len(updated.Finalizers) != 0 {
+		t.Errorf("expected finalizer to be removed, got %v", updated.Finalizers)
+	}
+}
+
+func TestRouteReconciler_GatewaySource(t *testing.T) {
+	route := routeWithParent("infra", "public")
+	route.Finalizers = []string{finalizerName}
+	route.Spec.Hostnames = []gatewayv1.Hostname{"app.unknown.com"}
+
+	fakeClient := newGatewayClient(t, route, newTestGateway("infra", "public", ipAddress("192.168.1.10")))
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver: &Resolver{
+			Reader:    fakeClient,
+			DomainMap: newTestDomainMap(t),
+			Source:    config.ValueSourceGateway,
+		},
+		DNS: mock,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected 1 created record, got %d", len(mock.createdRecords))
+	}
+	if !slices.Equal(mock.createdRecords[0].Values, []string{"192.168.1.10"}) {
+		t.Errorf("expected value '192.168.1.10' from Gateway status, got %q", mock.createdRecords[0].Values)
+	}
+}
+
+func TestRouteReconciler_GRPCRoute(t *testing.T) {
+	route := &gatewayv1.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "grpc",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.GRPCRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"grpc.my-domain1.com"},
+		},
+	}
+	fakeClient := newGatewayClient(t, route)
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Cluster:   "prod",
+		Kind:      GRPCRouteKind,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "grpc", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 || mock.createdRecords[0].Hostname != "grpc.my-domain1.com" {
+		t.Fatalf("expected a record for 'grpc.my-domain1.com', got %v", mock.createdRecords)
+	}
+
+	var updated gatewayv1.GRPCRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Annotations[managedHostnamesAnnotation] != `["grpc.my-domain1.com"]` {
+		t.Errorf("expected managed-hostnames annotation, got %q", updated.Annotations[managedHostnamesAnnotation])
+	}
+}
+
+func TestRouteReconciler_TLSRouteDeletion(t *testing.T) {
+	now := metav1.Now()
+	route := &gatewayv1alpha2.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "passthrough",
+			Namespace:         "default",
+			Finalizers:        []string{finalizerName},
+			DeletionTimestamp: &now,
+		},
+		Spec: gatewayv1alpha2.TLSRouteSpec{
+			Hostnames: []gatewayv1alpha2.Hostname{"db.my-domain1.com"},
+		},
+	}
+	fakeClient := newGatewayClient(t, route)
+
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "db.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"},
+				Meta: map[string]string{dns.MetaOwner: "prod/TLSRoute/default/passthrough"}},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Kind:      TLSRouteKind,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "passthrough", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.deletedHosts) != 1 || mock.deletedHosts[0] != "db.my-domain1.com" {
+		t.Fatalf("expected 'db.my-domain1.com' to be deleted, got %v", mock.deletedHosts)
+	}
+}
+
+func TestRouteReconciler_IngressStatusSource(t *testing.T) {
+	ing := newTestIngress("web", "app.unknown.com")
+	ing.Finalizers = []string{finalizerName}
+	ing.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"secure.unknown.com"}}}
+	ing.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "192.168.1.20"}}
+	fakeClient := newGatewayClient(t, ing)
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  &Resolver{Reader: fakeClient, Source: config.ValueSourceGateway},
+		DNS:       mock,
+		Kind:      IngressKind(""),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 2 {
+		t.Fatalf("expected 2 created records, got %v", mock.createdRecords)
+	}
+	for _, rec := range mock.createdRecords {
+		if !slices.Equal(rec.Values, []string{"192.168.1.20"}) {
+			t.Errorf("expected value '192.168.1.20' from Ingress status for %s, got %q", rec.Hostname, rec.Values)
+		}
+	}
+}
+
+func TestRouteReconciler_IngressClassFilter(t *testing.T) {
+	ing := newTestIngress("web", "app.my-domain1.com")
+	ing.Annotations = map[string]string{legacyIngressClassAnnotation: "traefik"}
+	fakeClient := newGatewayClient(t, ing)
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Kind:      IngressKind("nginx"),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var updated networkingv1.Ingress
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Finalizers) != 0 {
+		t.Errorf("expected Ingress of another class to be left alone, got finalizers %v", updated.Finalizers)
+	}
+}
+
+func newTestService(ips ...string) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mqtt",
+			Namespace:   "default",
+			Finalizers:  []string{finalizerName},
+			Annotations: map[string]string{hostnameAnnotation: "mqtt.unknown.com"},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	for _, ip := range ips {
+		svc.Status.LoadBalancer.Ingress = append(svc.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+	return svc
+}
+
+func TestRouteReconciler_ServiceDualStack(t *testing.T) {
+	fakeClient := newGatewayClient(t, newTestService("192.168.1.30", "fd00::30", "192.168.1.31"))
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Kind:      ServiceKind,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "mqtt", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 2 {
+		t.Fatalf("expected A and AAAA records, got %v", mock.createdRecords)
+	}
+	if rec := mock.createdRecords[0]; rec.Type != "A" || !slices.Equal(rec.Values, []string{"192.168.1.30", "192.168.1.31"}) {
+		t.Errorf("expected A record for 192.168.1.30 and 192.168.1.31, got %s %s", rec.Type, rec.Values)
+	}
+	if rec := mock.createdRecords[1]; rec.Type != "AAAA" || !slices.Equal(rec.Values, []string{"fd00::30"}) {
+		t.Errorf("expected AAAA record for fd00::30, got %s %s", rec.Type, rec.Values)
+	}
+}
+
+func TestRouteReconciler_ServiceDropsStaleAAAA(t *testing.T) {
+	fakeClient := newGatewayClient(t, newTestService("192.168.1.30"))
+
+	owned := map[string]string{dns.MetaOwner: "prod/Service/default/mqtt"}
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "mqtt.unknown.com", Type: "A", Values: []string{"192.168.1.30"}, Meta: owned},
+			{Hostname: "mqtt.unknown.com", Type: "AAAA", Values: []string{"fd00::30"}, Meta: owned},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Kind:      ServiceKind,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "mqtt", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 1 || len(mock.applied[0].Deletes) != 1 || mock.applied[0].Deletes[0].Type != "AAAA" {
+		t.Fatalf("expected only the AAAA record to be deleted, got %v", mock.applied)
+	}
+	if len(mock.createdRecords) != 0 {
+		t.Errorf("expected existing A record to be kept, got creates %v", mock.createdRecords)
+	}
+}
+
+func TestRouteReconciler_ServiceDeletionWithoutRecords(t *testing.T) {
+	svc := newTestService("192.168.1.30")
+	now := metav1.Now()
+	svc.DeletionTimestamp = &now
+	fakeClient := newGatewayClient(t, svc)
+
+	mock := &mockDNSProvider{}
+	recorder := events.NewFakeRecorder(10)
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Kind:      ServiceKind,
+		Cluster:   "prod",
+		Recorder:  recorder,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "mqtt", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, changes := range mock.applied {
+		if len(changes.Deletes) != 0 {
+			t.Errorf("expected no deletes for records that don't exist, got %v", changes.Deletes)
+		}
+	}
+	if got := drainEvents(recorder); len(got) != 0 {
+		t.Errorf("expected no events, got %q", got)
+	}
+}
+
+func TestRouteReconciler_DualStackDomainMap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "dual-route",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.dual.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	dm := loadTestDomainMap(t, `dual.com: ["10.0.8.100", "fd00::100"]`)
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  &Resolver{DomainMap: dm},
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "dual-route", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 2 {
+		t.Fatalf("expected A and AAAA records, got %v", mock.createdRecords)
+	}
+	if rec := mock.createdRecords[0]; rec.Type != "A" || !slices.Equal(rec.Values, []string{"10.0.8.100"}) {
+		t.Errorf("expected A record for 10.0.8.100, got %s %s", rec.Type, rec.Values)
+	}
+	if rec := mock.createdRecords[1]; rec.Type != "AAAA" || !slices.Equal(rec.Values, []string{"fd00::100"}) {
+		t.Errorf("expected AAAA record for fd00::100, got %s %s", rec.Type, rec.Values)
+	}
+
+	// Deleting the route removes both records.
+	mock.listedRecords = mock.createdRecords
+	if err := fakeClient.Delete(context.Background(), route); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error on deletion: %v", err)
+	}
+	deletes := mock.applied[len(mock.applied)-1].Deletes
+	if len(deletes) != 2 || deletes[0].Type != "A" || deletes[1].Type != "AAAA" {
+		t.Errorf("expected A and AAAA deletes, got %v", deletes)
+	}
+}
+
+func TestRouteReconciler_RoundRobinDomainMap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "rr-route",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.nodes.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	dm := loadTestDomainMap(t, `nodes.com: [10.0.8.1, 10.0.8.2, 10.0.8.3]`)
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  &Resolver{DomainMap: dm},
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "rr-route", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected a single A record, got %v", mock.createdRecords)
+	}
+	want := []string{"10.0.8.1", "10.0.8.2", "10.0.8.3"}
+	if rec := mock.createdRecords[0]; rec.Type != "A" || !slices.Equal(rec.Values, want) {
+		t.Errorf("expected A record for %v, got %s %v", want, rec.Type, rec.Values)
+	}
+}
+
+func TestRouteReconciler_DomainMapTTLAndDescription(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "web",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.volatile.com", "legacy.volatile.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	dm := loadTestDomainMap(t, `
+version: 2
+domains:
+  "*.volatile.com":
+    values: [10.0.8.100]
+    ttl: 30
+    description: "{{ .Kind }} {{ .Namespace }}/{{ .Name }}"
+    exclude: [legacy.volatile.com]
+`)
+
+	mock := &mockDNSProvider{}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  &Resolver{DomainMap: dm},
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected only the non-excluded hostname, got %v", mock.createdRecords)
+	}
+	rec := mock.createdRecords[0]
+	if rec.Hostname != "app.volatile.com" || rec.TTL != 30 {
+		t.Errorf("expected app.volatile.com with TTL 30, got %s with TTL %d", rec.Hostname, rec.TTL)
+	}
+	if got := rec.Meta["description"]; got != "HTTPRoute default/web" {
+		t.Errorf("expected rendered description, got %q", got)
+	}
+	if rec.Owner() != "prod/HTTPRoute/default/web" {
+		t.Errorf("expected owner to be kept alongside the description, got %q", rec.Owner())
+	}
+}
+
+func TestRouteReconciler_SwitchToCNAME(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "cname-route",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	// The entry used to map to an IP, so the route still has an A record.
+	mock := &mockDNSProvider{
+		listedRecords: []dns.Record{
+			{Hostname: "app.my-domain1.com", Type: "A", Values: []string{"10.0.8.100"}, Meta: map[string]string{dns.MetaOwner: "prod/HTTPRoute/default/cname-route"}},
+		},
+	}
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  &Resolver{DomainMap: loadTestDomainMap(t, "my-domain1.com: {cname: gateway.my-domain1.com}\n")},
+		DNS:       mock,
+		Cluster:   "prod",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cname-route", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.applied) != 1 {
+		t.Fatalf("expected 1 ApplyChanges call, got %d", len(mock.applied))
+	}
+	changes := mock.applied[0]
+	if len(changes.Creates) != 1 || changes.Creates[0].Type != "CNAME" || !slices.Equal(changes.Creates[0].Values, []string{"gateway.my-domain1.com"}) {
+		t.Errorf("expected a CNAME to gateway.my-domain1.com, got %v", changes.Creates)
+	}
+	if len(changes.Deletes) != 1 || changes.Deletes[0].Type != "A" {
+		t.Errorf("expected the old A record to be deleted, got %v", changes.Deletes)
+	}
+}
+
+func TestRouteReconciler_EventsAndStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "status-route",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com", "nas.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{
+		existingHosts: map[string]bool{"nas.my-domain1.com": true},
+		listedRecords: []dns.Record{
+			{Hostname: "nas.my-domain1.com", Type: "A", Meta: map[string]string{"description": "my NAS"}},
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Upsert:    true,
+		Cluster:   "prod",
+		Recorder:  recorder,
+	}
+
+	created := testutil.ToFloat64(recordChangesTotal.WithLabelValues("HTTPRoute", changeCreated))
+	updated := testutil.ToFloat64(recordChangesTotal.WithLabelValues("HTTPRoute", changeUpdated))
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "status-route", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The refused update is not counted as a change.
+	if got := testutil.ToFloat64(recordChangesTotal.WithLabelValues("HTTPRoute", changeCreated)); got != created+1 {
+		t.Errorf("expected 1 created record counted, got %v", got-created)
+	}
+	if got := testutil.ToFloat64(recordChangesTotal.WithLabelValues("HTTPRoute", changeUpdated)); got != updated {
+		t.Errorf("expected no updated record counted, got %v", got-updated)
+	}
+
+	got := drainEvents(recorder)
+	want := []string{
+		`Warning Failed record not owned: nas.my-domain1.com/A was not created by yk-dns-manager`,
+		`Normal Created created A record app.my-domain1.com -> 10.0.8.100`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected events %q, got %q", want, got)
+	}
+
+	var updatedRoute gatewayv1.HTTPRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updatedRoute); err != nil {
+		t.Fatal(err)
+	}
+	statuses := readStatus(&updatedRoute)
+	if s := statuses["app.my-domain1.com"]; s.State != stateReady || len(s.Records) != 1 || s.Records[0].Value != "10.0.8.100" {
+		t.Errorf("expected app.my-domain1.com to be Ready with its record, got %+v", s)
+	}
+	if s := statuses["nas.my-domain1.com"]; s.State != stateFailed || s.Error == "" {
+		t.Errorf("expected nas.my-domain1.com to be Failed with an error, got %+v", s)
+	}
+	if _, ok := updatedRoute.Annotations[managedHostnamesAnnotation]; !ok {
+		t.Error("expected managed-hostnames annotation to be set")
+	}
+}
+
+func TestRouteReconciler_ApplyFailureStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1.Install(scheme); err != nil {
+		t.Fatalf("failed to install gateway-api scheme: %v", err)
+	}
+
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "failing-route",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"app.my-domain1.com"},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&gatewayv1.HTTPRoute{}, hostnameIndex, HTTPRouteKind.indexHostnames).
+		WithObjects(route).
+		Build()
+
+	mock := &mockDNSProvider{applyErr: errors.New("connection refused")}
+	recorder := events.NewFakeRecorder(10)
+	reconciler := &RouteReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       zap.New(zap.UseDevMode(true)),
+		Resolver:  newTestResolver(t),
+		DNS:       mock,
+		Recorder:  recorder,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "failing-route", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err == nil {
+		t.Fatal("expected apply error to be returned")
+	}
+
+	got := drainEvents(recorder)
+	if len(got) != 1 || got[0] != "Warning Failed applying DNS changes: connection refused" {
+		t.Errorf("expected a single Failed event, got %q", got)
+	}
+
+	var updated gatewayv1.HTTPRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	s := readStatus(&updated)["app.my-domain1.com"]
+	if s.State != stateFailed || s.Error != "applying DNS changes: connection refused" {
+		t.Errorf("expected app.my-domain1.com to be Failed with the provider error, got %+v", s)
+	}
+	// The hostname is not recorded as managed until its records are applied.
+	if _, ok := updated.Annotations[managedHostnamesAnnotation]; ok {
+		t.Error("expected managed-hostnames annotation to be left unset")
+	}
+}
+
+// drainEvents returns the events emitted so far.
+func drainEvents(recorder *events.FakeRecorder) []string {
+	var got []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// statusAnnotation holds the DNS status of each managed hostname of a route
+// as a JSON object keyed by hostname. Routes are owned by other controllers,
+// so the status is kept in an annotation rather than in their status.
+const statusAnnotation = "dns.yk/status"
+
+// Hostname states reported in the status annotation.
+const (
+	stateReady   = "Ready"   // all records of the hostname are applied
+	statePending = "Pending" // no address is available for the hostname yet
+	stateFailed  = "Failed"  // the last attempt to apply the records failed
+	// stateConflict means another route claiming the hostname holds its record
+	// with different values.
+	stateConflict = "Conflict"
+	// stateUnsupported means the hostname is a wildcard the DNS provider can't
+	// publish.
+	stateUnsupported = "Unsupported"
+)
+
+// Event reasons emitted on routes.
+const (
+	reasonCreated     = "Created"
+	reasonUpdated     = "Updated"
+	reasonDeleted     = "Deleted"
+	reasonFailed      = "Failed"
+	reasonHandedOver  = "HandedOver"
+	reasonConflict    = "Conflict"
+	reasonUnsupported = "Unsupported"
+	reasonRejected    = "Rejected"   // the DNS server rejected the records as invalid
+	reasonAuthFailed  = "AuthFailed" // the DNS server rejected the credentials
+)
+
+// hostnameStatus is the DNS status of a single hostname.
+type hostnameStatus struct {
+	State   string   `json:"state"`
+	Records []target `json:"records,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// readStatus returns the hostname statuses recorded on obj.
+func readStatus(obj client.Object) map[string]hostnameStatus {
+	statuses := make(map[string]hostnameStatus)
+	if val, ok := obj.GetAnnotations()[statusAnnotation]; ok {
+		_ = json.Unmarshal([]byte(val), &statuses)
+	}
+	return statuses
+}
+
+// setStatusAnnotation stores statuses on obj, removing the annotation when
+// there is nothing to report. It reports whether the annotation changed.
+func setStatusAnnotation(obj client.Object, statuses map[string]hostnameStatus) bool {
+	annotations := obj.GetAnnotations()
+	old, had := annotations[statusAnnotation]
+	if len(statuses) == 0 {
+		if !had {
+			return false
+		}
+		delete(annotations, statusAnnotation)
+		obj.SetAnnotations(annotations)
+		return true
+	}
+	// Map keys are marshalled in sorted order, so equal statuses compare equal.
+	data, _ := json.Marshal(statuses)
+	if had && old == string(data) {
+		return false
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[statusAnnotation] = string(data)
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// writeStatus stores statuses in the status annotation of the route, leaving
+// the managed-hostnames annotation untouched.
+func (r *RouteReconciler) writeStatus(ctx context.Context, key types.NamespacedName, route client.Object, statuses map[string]hostnameStatus) error {
+	if !setStatusAnnotation(route.DeepCopyObject().(client.Object), statuses) {
+		return nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.APIReader.Get(ctx, key, route); err != nil {
+			return err
+		}
+		setStatusAnnotation(route, statuses)
+		return r.Update(ctx, route)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update status annotation: %w", err)
+	}
+	return nil
+}
+
+// failed records err as the status of hostname, emits a Warning event and
+// returns err. A failure to store the status is only logged so that the
+// original error is what gets retried.
+func (r *RouteReconciler) failed(ctx context.Context, key types.NamespacedName, route client.Object, statuses map[string]hostnameStatus, hostname, action string, err error) error {
+	statuses[hostname] = hostnameStatus{State: stateFailed, Records: statuses[hostname].Records, Error: err.Error()}
+	r.event(route, corev1.EventTypeWarning, failureReason(err), action, "%s: %v", hostname, err)
+	if statusErr := r.writeStatus(ctx, key, route, statuses); statusErr != nil {
+		r.Log.Error(statusErr, "failed to record DNS status", "name", key)
+	}
+	return err
+}
+
+// event emits an event on the route if the reconciler has a recorder.
+func (r *RouteReconciler) event(route client.Object, eventType, reason, action, note string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(route, nil, eventType, reason, action, note, args...)
+}
+
+// refusedRecords returns the ownership errors in err keyed by recordKey.
+func refusedRecords(err error) map[string]*dns.OwnershipError {
+	refused := make(map[string]*dns.OwnershipError)
+	for _, e := range dns.OwnershipErrors(err) {
+		refused[recordKey(dns.Record{Hostname: e.Hostname, Type: e.Type})] = e
+	}
+	return refused
+}
+
+// recordEvents emits an event for each change that was applied, skipping
+// records the provider refused, and counts them in the record changes metric.
+func (r *RouteReconciler) recordEvents(route client.Object, changes dns.ChangeSet, refused map[string]*dns.OwnershipError) {
+	countChanges(r.kind().Kind, changes, refused)
+	for _, rec := range changes.Creates {
+		if refused[recordKey(rec)] == nil {
+			r.event(route, corev1.EventTypeNormal, reasonCreated, "CreateRecord",
+				"created %s record %s -> %s", rec.Type, rec.Hostname, strings.Join(rec.Values, ", "))
+		}
+	}
+	for _, rec := range changes.Updates {
+		if refused[recordKey(rec)] == nil {
+			r.event(route, corev1.EventTypeNormal, reasonUpdated, "UpdateRecord",
+				"updated %s record %s -> %s", rec.Type, rec.Hostname, strings.Join(rec.Values, ", "))
+		}
+	}
+	for _, rec := range changes.Deletes {
+		if refused[recordKey(rec)] == nil {
+			r.event(route, corev1.EventTypeNormal, reasonDeleted, "DeleteRecord",
+				"deleted %s record %s", rec.Type, rec.Hostname)
+		}
+	}
+}
+
+// handoverEvents emits an event for each record handed over to another route
+// claiming its hostname, skipping records the provider refused.
+func (r *RouteReconciler) handoverEvents(route client.Object, handovers []dns.Record, refused map[string]*dns.OwnershipError) {
+	for _, rec := range handovers {
+		if refused[recordKey(rec)] == nil {
+			r.event(route, corev1.EventTypeNormal, reasonHandedOver, "HandOverRecord",
+				"kept %s record %s for %s, which still claims it", rec.Type, rec.Hostname, rec.Owner())
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/config"
+)
+
+// RouteKind adapts a resource type that carries hostnames, such as a Gateway
+// API route or an Ingress, so the reconciler, drift resync and garbage
+// collection can handle every such kind the same way.
+type RouteKind struct {
+	Kind       string // e.g. "HTTPRoute"
+	newObject  func() client.Object
+	newList    func() client.ObjectList
+	items      func(client.ObjectList) []client.Object
+	hostnames  func(client.Object) []string
+	parentRefs func(client.Object) []gatewayv1.ParentReference // nil for kinds not attached to Gateways
+	// ownAddresses returns the load balancer addresses published in the
+	// object's own status, for kinds not attached to Gateways.
+	ownAddresses func(client.Object) statusAddresses
+	// selects reports whether the object should be managed at all; nil selects every object.
+	selects func(client.Object) bool
+	// statusValues makes every hostname managed, with A and AAAA values taken
+	// from ownAddresses regardless of the configured value source.
+	statusValues bool
+}
+
+// HTTPRouteKind handles gateway.networking.k8s.io/v1 HTTPRoutes.
+var HTTPRouteKind = RouteKind{
+	Kind:      config.RouteKindHTTPRoute,
+	newObject: func() client.Object { return &gatewayv1.HTTPRoute{} },
+	newList:   func() client.ObjectList { return &gatewayv1.HTTPRouteList{} },
+	items: func(list client.ObjectList) []client.Object {
+		routes := list.(*gatewayv1.HTTPRouteList)
+		objs := make([]client.Object, len(routes.Items))
+		for i := range routes.Items {
+			objs[i] = &routes.Items[i]
+		}
+		return objs
+	},
+	hostnames: func(obj client.Object) []string {
+		return hostnameStrings(obj.(*gatewayv1.HTTPRoute).Spec.Hostnames)
+	},
+	parentRefs: func(obj client.Object) []gatewayv1.ParentReference {
+		return obj.(*gatewayv1.HTTPRoute).Spec.ParentRefs
+	},
+}
+
+// GRPCRouteKind handles gateway.networking.k8s.io/v1 GRPCRoutes.
+var GRPCRouteKind = RouteKind{
+	Kind:      config.RouteKindGRPCRoute,
+	newObject: func() client.Object { return &gatewayv1.GRPCRoute{} },
+	newList:   func() client.ObjectList { return &gatewayv1.GRPCRouteList{} },
+	items: func(list client.ObjectList) []client.Object {
+		routes := list.(*gatewayv1.GRPCRouteList)
+		objs := make([]client.Object, len(routes.Items))
+		for i := range routes.Items {
+			objs[i] = &routes.Items[i]
+		}
+		return objs
+	},
+	hostnames: func(obj client.Object) []string {
+		return hostnameStrings(obj.(*gatewayv1.GRPCRoute).Spec.Hostnames)
+	},
+	parentRefs: func(obj client.Object) []gatewayv1.ParentReference {
+		return obj.(*gatewayv1.GRPCRoute).Spec.ParentRefs
+	},
+}
+
+// TLSRouteKind handles gateway.networking.k8s.io/v1alpha2 TLSRoutes, whose
+// hostnames are SNI names.
+var TLSRouteKind = RouteKind{
+	Kind:      config.RouteKindTLSRoute,
+	newObject: func() client.Object { return &gatewayv1alpha2.TLSRoute{} },
+	newList:   func() client.ObjectList { return &gatewayv1alpha2.TLSRouteList{} },
+	items: func(list client.ObjectList) []client.Object {
+		routes := list.(*gatewayv1alpha2.TLSRouteList)
+		objs := make([]client.Object, len(routes.Items))
+		for i := range routes.Items {
+			objs[i] = &routes.Items[i]
+		}
+		return objs
+	},
+	hostnames: func(obj client.Object) []string {
+		return hostnameStrings(obj.(*gatewayv1alpha2.TLSRoute).Spec.Hostnames)
+	},
+	parentRefs: func(obj client.Object) []gatewayv1.ParentReference {
+		return obj.(*gatewayv1alpha2.TLSRoute).Spec.ParentRefs
+	},
+}
+
+// IngressKind handles networking.k8s.io/v1 Ingresses, taking hostnames from
+// both spec.rules[].host and spec.tls[].hosts. When class is not empty only
+// Ingresses of that IngressClass are selected.
+func IngressKind(class string) RouteKind {
+	return RouteKind{
+		Kind:      config.RouteKindIngress,
+		newObject: func() client.Object { return &networkingv1.Ingress{} },
+		newList:   func() client.ObjectList { return &networkingv1.IngressList{} },
+		items: func(list client.ObjectList) []client.Object {
+			ingresses := list.(*networkingv1.IngressList)
+			objs := make([]client.Object, len(ingresses.Items))
+			for i := range ingresses.Items {
+				objs[i] = &ingresses.Items[i]
+			}
+			return objs
+		},
+		hostnames: func(obj client.Object) []string {
+			return ingressHostnames(obj.(*networkingv1.Ingress))
+		},
+		ownAddresses: func(obj client.Object) statusAddresses {
+			return ingressAddresses(obj.(*networkingv1.Ingress))
+		},
+		selects: func(obj client.Object) bool {
+			return class == "" || ingressClass(obj.(*networkingv1.Ingress)) == class
+		},
+	}
+}
+
+// legacyIngressClassAnnotation predates spec.ingressClassName but is still set
+// by many charts.
+const legacyIngressClassAnnotation = "kubernetes.io/ingress.class"
+
+// ingressClass returns the IngressClass an Ingress belongs to.
+func ingressClass(ing *networkingv1.Ingress) string {
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName
+	}
+	return ing.Annotations[legacyIngressClassAnnotation]
+}
+
+// ingressHostnames returns the distinct hostnames of an Ingress's rules and
+// TLS sections, in the order they appear.
+func ingressHostnames(ing *networkingv1.Ingress) []string {
+	var hostnames []string
+	add := func(h string) {
+		if h != "" && !Contains(hostnames, h) {
+			hostnames = append(hostnames, h)
+		}
+	}
+	for _, rule := range ing.Spec.Rules {
+		add(rule.Host)
+	}
+	for _, tls := range ing.Spec.TLS {
+		for _, h := range tls.Hosts {
+			add(h)
+		}
+	}
+	return hostnames
+}
+
+// ingressAddresses returns the load balancer addresses in an Ingress's status.
+func ingressAddresses(ing *networkingv1.Ingress) statusAddresses {
+	var addrs statusAddresses
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addrs.addIP(lb.IP)
+		}
+		if lb.Hostname != "" && !Contains(addrs.Hostnames, lb.Hostname) {
+			addrs.Hostnames = append(addrs.Hostnames, lb.Hostname)
+		}
+	}
+	return addrs
+}
+
+// hostnameAnnotation lists the hostnames, comma-separated, for kinds that have
+// no hostname field of their own.
+const hostnameAnnotation = "dns.yk/hostname"
+
+// ServiceKind handles core/v1 Services of type LoadBalancer. Hostnames come
+// from the dns.yk/hostname annotation and records always point to the
+// addresses in the Service's load balancer status.
+var ServiceKind = RouteKind{
+	Kind:      config.RouteKindService,
+	newObject: func() client.Object { return &corev1.Service{} },
+	newList:   func() client.ObjectList { return &corev1.ServiceList{} },
+	items: func(list client.ObjectList) []client.Object {
+		services := list.(*corev1.ServiceList)
+		objs := make([]client.Object, len(services.Items))
+		for i := range services.Items {
+			objs[i] = &services.Items[i]
+		}
+		return objs
+	},
+	hostnames: func(obj client.Object) []string {
+		return annotationHostnames(obj)
+	},
+	ownAddresses: func(obj client.Object) statusAddresses {
+		return serviceAddresses(obj.(*corev1.Service))
+	},
+	selects: func(obj client.Object) bool {
+		return obj.(*corev1.Service).Spec.Type == corev1.ServiceTypeLoadBalancer
+	},
+	statusValues: true,
+}
+
+// annotationHostnames returns the distinct hostnames listed in an object's
+// dns.yk/hostname annotation.
+func annotationHostnames(obj client.Object) []string {
+	var hostnames []string
+	for _, h := range strings.Split(obj.GetAnnotations()[hostnameAnnotation], ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !Contains(hostnames, h) {
+			hostnames = append(hostnames, h)
+		}
+	}
+	return hostnames
+}
+
+// serviceAddresses returns the load balancer addresses in a Service's status.
+func serviceAddresses(svc *corev1.Service) statusAddresses {
+	var addrs statusAddresses
+	for _, lb := range svc.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addrs.addIP(lb.IP)
+		}
+		if lb.Hostname != "" && !Contains(addrs.Hostnames, lb.Hostname) {
+			addrs.Hostnames = append(addrs.Hostnames, lb.Hostname)
+		}
+	}
+	return addrs
+}
+
+// RouteKindsByName returns the route kinds for the given kind names.
+// An empty list selects HTTPRoute only. ingressClass restricts the Ingress
+// kind to a single IngressClass.
+func RouteKindsByName(names []string, ingressClass string) ([]RouteKind, error) {
+	if len(names) == 0 {
+		return []RouteKind{HTTPRouteKind}, nil
+	}
+	kinds := make([]RouteKind, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case config.RouteKindHTTPRoute:
+			kinds = append(kinds, HTTPRouteKind)
+		case config.RouteKindGRPCRoute:
+			kinds = append(kinds, GRPCRouteKind)
+		case config.RouteKindTLSRoute:
+			kinds = append(kinds, TLSRouteKind)
+		case config.RouteKindIngress:
+			kinds = append(kinds, IngressKind(ingressClass))
+		case config.RouteKindService:
+			kinds = append(kinds, ServiceKind)
+		default:
+			return nil, fmt.Errorf("unsupported route kind %q", name)
+		}
+	}
+	return kinds, nil
+}
+
+// orDefaultKinds returns kinds, or HTTPRoute only when none are configured.
+func orDefaultKinds(kinds []RouteKind) []RouteKind {
+	if len(kinds) == 0 {
+		return []RouteKind{HTTPRouteKind}
+	}
+	return kinds
+}
+
+// hostnameStrings converts Gateway API hostnames to plain strings.
+func hostnameStrings(hostnames []gatewayv1.Hostname) []string {
+	out := make([]string, 0, len(hostnames))
+	for _, h := range hostnames {
+		out = append(out, string(h))
+	}
+	return out
+}
+
+// attachesToGateways reports whether objects of this kind reference parent Gateways.
+func (k RouteKind) attachesToGateways() bool {
+	return k.parentRefs != nil
+}
+
+// parentGateways returns the Gateways referenced by a route's parentRefs.
+func (k RouteKind) parentGateways(obj client.Object) []types.NamespacedName {
+	if k.parentRefs == nil {
+		return nil
+	}
+	return parentGateways(obj.GetNamespace(), k.parentRefs(obj))
+}
+
+// statusAddresses returns the load balancer addresses for an object, either
+// from its own status or from the status of its parent Gateways.
+func (k RouteKind) statusAddresses(ctx context.Context, v *Resolver, obj client.Object) (statusAddresses, error) {
+	if k.ownAddresses != nil {
+		return k.ownAddresses(obj), nil
+	}
+	return v.gatewayAddresses(ctx, k.parentGateways(obj))
+}
+
+// selected reports whether the object should be managed.
+func (k RouteKind) selected(obj client.Object) bool {
+	return k.selects == nil || k.selects(obj)
+}
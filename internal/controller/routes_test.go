@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestIngress(name string, hosts ...string) *networkingv1.Ingress {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+	}
+	for _, h := range hosts {
+		ing.Spec.Rules = append(ing.Spec.Rules, networkingv1.IngressRule{Host: h})
+	}
+	return ing
+}
+
+func TestIngressHostnames(t *testing.T) {
+	ing := newTestIngress("web", "app.my-domain1.com", "", "api.my-domain1.com")
+	ing.Spec.TLS = []networkingv1.IngressTLS{
+		{Hosts: []string{"app.my-domain1.com", "secure.my-domain1.com"}},
+	}
+
+	got := IngressKind("").hostnames(ing)
+	want := []string{"app.my-domain1.com", "api.my-domain1.com", "secure.my-domain1.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hostnames = %v, want %v", got, want)
+	}
+}
+
+func TestIngressKind_ClassFilter(t *testing.T) {
+	nginx := "nginx"
+	withClassName := newTestIngress("a")
+	withClassName.Spec.IngressClassName = &nginx
+	withAnnotation := newTestIngress("b")
+	withAnnotation.Annotations = map[string]string{legacyIngressClassAnnotation: "nginx"}
+	other := newTestIngress("c")
+	other.Annotations = map[string]string{legacyIngressClassAnnotation: "traefik"}
+
+	kind := IngressKind("nginx")
+	if !kind.selected(withClassName) {
+		t.Error("expected Ingress with spec.ingressClassName nginx to be selected")
+	}
+	if !kind.selected(withAnnotation) {
+		t.Error("expected Ingress with legacy class annotation nginx to be selected")
+	}
+	if kind.selected(other) {
+		t.Error("expected Ingress of class traefik not to be selected")
+	}
+	if !IngressKind("").selected(other) {
+		t.Error("expected every Ingress to be selected without a class filter")
+	}
+}
+
+func TestIngressAddresses(t *testing.T) {
+	ing := newTestIngress("web")
+	ing.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{
+		{Hostname: "lb.example.net"},
+		{IP: "fd00::1"},
+		{IP: "192.168.1.20"},
+		{IP: "192.168.1.20"},
+	}
+
+	addrs := ingressAddresses(ing)
+	if !reflect.DeepEqual(addrs.IPv4, []string{"192.168.1.20"}) {
+		t.Errorf("IPv4 = %v", addrs.IPv4)
+	}
+	if !reflect.DeepEqual(addrs.IPv6, []string{"fd00::1"}) {
+		t.Errorf("IPv6 = %v", addrs.IPv6)
+	}
+	if !reflect.DeepEqual(addrs.Hostnames, []string{"lb.example.net"}) {
+		t.Errorf("Hostnames = %v", addrs.Hostnames)
+	}
+}
+
+func TestAnnotationHostnames(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mqtt",
+			Namespace:   "default",
+			Annotations: map[string]string{hostnameAnnotation: " mqtt.my-domain1.com, ,broker.my-domain1.com,mqtt.my-domain1.com"},
+		},
+	}
+
+	got := ServiceKind.hostnames(svc)
+	want := []string{"mqtt.my-domain1.com", "broker.my-domain1.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hostnames = %v, want %v", got, want)
+	}
+}
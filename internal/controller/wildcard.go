@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// publishable drops the targets of a wildcard hostname the provider can't
+// publish as wildcard records, returning the remaining targets and the record
+// types dropped. Targets of other hostnames are returned as they are.
+func publishable(provider dns.Provider, hostname string, targets []target) ([]target, []string) {
+	if !dns.IsWildcard(hostname) {
+		return targets, nil
+	}
+	var kept []target
+	var unsupported []string
+	for _, t := range targets {
+		switch {
+		case dns.SupportsWildcard(provider, t.Type):
+			kept = append(kept, t)
+		case !Contains(unsupported, t.Type):
+			unsupported = append(unsupported, t.Type)
+		}
+	}
+	return kept, unsupported
+}
+
+// unsupportedWildcardMessage describes the wildcard records of hostname the
+// provider can't publish.
+func unsupportedWildcardMessage(hostname string, recordTypes []string) string {
+	return fmt.Sprintf("the DNS provider can't publish wildcard %s records, skipping %s",
+		strings.Join(recordTypes, "/"), hostname)
+}
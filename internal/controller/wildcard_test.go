@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestRouteReconciler_WildcardHostname(t *testing.T) {
+	route := newSharedRoute("apps", "*.apps.my-domain1.com", "*.web.my-domain1.com")
+	mock := &mockDNSProvider{wildcardTypes: []string{"A", "AAAA"}}
+	recorder := events.NewFakeRecorder(10)
+	reconciler, fakeClient := newClaimsReconciler(t, mock, recorder, route)
+	reconciler.Resolver.DomainMap = loadTestDomainMap(t, `
+my-domain1.com: 10.0.8.100
+"*.web.my-domain1.com": {cname: gateway.my-domain1.com}
+`)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "apps", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.createdRecords) != 1 {
+		t.Fatalf("expected only the wildcard A record to be created, got %v", mock.createdRecords)
+	}
+	if rec := mock.createdRecords[0]; rec.Hostname != "*.apps.my-domain1.com" || rec.Type != "A" {
+		t.Errorf("expected an A record for *.apps.my-domain1.com, got %+v", rec)
+	}
+
+	const msg = "the DNS provider can't publish wildcard CNAME records, skipping *.web.my-domain1.com"
+	if got := drainEvents(recorder); !slices.Contains(got, "Warning Unsupported "+msg) {
+		t.Errorf("expected an Unsupported event, got %q", got)
+	}
+	var updated gatewayv1.HTTPRoute
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatal(err)
+	}
+	statuses := readStatus(&updated)
+	if s := statuses["*.apps.my-domain1.com"]; s.State != stateReady {
+		t.Errorf("expected the wildcard A hostname to be Ready, got %+v", s)
+	}
+	if s := statuses["*.web.my-domain1.com"]; s.State != stateUnsupported || s.Error != msg {
+		t.Errorf("expected the wildcard CNAME hostname to be Unsupported, got %+v", s)
+	}
+}
+
+func TestDriftResyncer_SkipsUnsupportedWildcard(t *testing.T) {
+	route := newSharedRoute("apps", "*.apps.my-domain1.com", "app.my-domain1.com")
+	mock := &mockDNSProvider{}
+	resyncer := newResyncer(t, mock, true, route)
+
+	if err := resyncer.Resync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var hostnames []string
+	for _, rec := range mock.createdRecords {
+		hostnames = append(hostnames, rec.Hostname)
+	}
+	if !slices.Equal(hostnames, []string{"app.my-domain1.com"}) {
+		t.Errorf("expected only the plain hostname to be recreated, got %v", hostnames)
+	}
+}
@@ -0,0 +1,148 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrCircuitOpen is returned, together with ErrRetryable, for calls a circuit
+// breaker rejects without passing them to the provider.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerPolicy controls when a circuit breaker stops passing calls to the
+// provider.
+type BreakerPolicy struct {
+	FailureThreshold int           // consecutive failed calls that open the circuit, 0 disables the breaker
+	OpenDuration     time.Duration // how long the circuit stays open before a trial call is let through
+}
+
+// WithCircuitBreaker returns a middleware that stops calling an unreachable
+// or failing DNS server. After FailureThreshold consecutive calls failed with
+// a retryable error, see IsRetryable, the circuit opens and calls fail right
+// away with ErrCircuitOpen. Once OpenDuration has passed, a single trial call
+// is let through: the circuit closes if it succeeds and opens again if it
+// fails. Any other outcome, including errors the DNS server answered with,
+// counts as a success. HealthCheck always reaches the provider.
+func WithCircuitBreaker(policy BreakerPolicy, log logr.Logger) Middleware {
+	if policy.FailureThreshold <= 0 {
+		return noMiddleware
+	}
+	return func(p Provider) Provider {
+		return &breaker{wrapped: wrapped{p}, policy: policy, log: log, now: time.Now}
+	}
+}
+
+type breaker struct {
+	wrapped
+	policy BreakerPolicy
+	log    logr.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	failures int       // consecutive failed calls
+	openedAt time.Time // when the circuit opened, zero while closed
+	trial    bool      // a trial call is in flight
+}
+
+// allow reports whether a call may go ahead and whether it is the trial call
+// of an open circuit, returning the error to fail it with otherwise.
+func (b *breaker) allow() (trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return false, nil
+	}
+	if wait := b.openedAt.Add(b.policy.OpenDuration).Sub(b.now()); wait > 0 || b.trial {
+		return false, Classify(fmt.Errorf("dns: %w after %d failed calls, retrying in %s",
+			ErrCircuitOpen, b.failures, max(wait, 0).Round(time.Second)), ErrRetryable)
+	}
+	b.trial = true
+	return true, nil
+}
+
+// record counts the outcome of a call allow let through.
+func (b *breaker) record(trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial {
+		b.trial = false
+	}
+	if !IsRetryable(err) {
+		if !b.openedAt.IsZero() {
+			b.log.Info("DNS provider answering again, closing circuit breaker")
+		}
+		b.failures, b.openedAt = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if trial || b.openedAt.IsZero() && b.failures >= b.policy.FailureThreshold {
+		b.openedAt = b.now()
+		b.log.Info("DNS provider failing, opening circuit breaker",
+			"failures", b.failures, "openDuration", b.policy.OpenDuration, "error", err.Error())
+	}
+}
+
+// do runs fn unless the circuit is open. Calls ended by ctx say nothing about
+// the DNS server and are not counted.
+func (b *breaker) do(ctx context.Context, fn func() error) error {
+	trial, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	if ctx.Err() != nil {
+		if trial {
+			b.mu.Lock()
+			b.trial = false
+			b.mu.Unlock()
+		}
+		return err
+	}
+	b.record(trial, err)
+	return err
+}
+
+func (b *breaker) Exists(ctx context.Context, hostname, recordType string) (bool, error) {
+	var exists bool
+	err := b.do(ctx, func() error {
+		var err error
+		exists, err = b.Provider.Exists(ctx, hostname, recordType)
+		return err
+	})
+	return exists, err
+}
+
+func (b *breaker) List(ctx context.Context, filter ListFilter) ([]Record, error) {
+	var records []Record
+	err := b.do(ctx, func() error {
+		var err error
+		records, err = b.Provider.List(ctx, filter)
+		return err
+	})
+	return records, err
+}
+
+func (b *breaker) Create(ctx context.Context, record Record) error {
+	return b.do(ctx, func() error { return b.Provider.Create(ctx, record) })
+}
+
+func (b *breaker) Update(ctx context.Context, record Record) error {
+	return b.do(ctx, func() error { return b.Provider.Update(ctx, record) })
+}
+
+func (b *breaker) Delete(ctx context.Context, hostname, recordType string) error {
+	return b.do(ctx, func() error { return b.Provider.Delete(ctx, hostname, recordType) })
+}
+
+func (b *breaker) Upsert(ctx context.Context, record Record) error {
+	return b.do(ctx, func() error { return b.Provider.Upsert(ctx, record) })
+}
+
+func (b *breaker) ApplyChanges(ctx context.Context, changes ChangeSet) error {
+	return b.do(ctx, func() error { return b.Provider.ApplyChanges(ctx, changes) })
+}
@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ChangeSet groups record changes that should be submitted to a provider together.
+// Deletes only use the Hostname, Type and owner of each record.
+type ChangeSet struct {
+	Creates []Record
+	Updates []Record
+	Deletes []Record
+}
+
+// IsEmpty reports whether the change set contains no changes.
+func (c ChangeSet) IsEmpty() bool {
+	return len(c.Creates) == 0 && len(c.Updates) == 0 && len(c.Deletes) == 0
+}
+
+// ApplySequentially applies a change set one record at a time using the
+// provider's single-record methods. Providers that cannot batch changes can
+// use it to implement ApplyChanges. Deletes run first so that a hostname being
+// moved between record types is freed before it is recreated.
+//
+// Updates and deletes of records owned by someone else are skipped; once the
+// remaining changes are applied they are reported in an error wrapping ErrNotOwned.
+// Updates of records that are already Unchanged are skipped as well.
+func ApplySequentially(ctx context.Context, p Provider, changes ChangeSet) error {
+	deletes, deniedDeletes, err := filterOwned(ctx, p, changes.Deletes)
+	if err != nil {
+		return err
+	}
+	updates, deniedUpdates, err := filterOwned(ctx, p, changes.Updates)
+	if err != nil {
+		return err
+	}
+	if updates, err = filterChanged(ctx, p, updates); err != nil {
+		return err
+	}
+
+	for _, rec := range deletes {
+		if err := p.Delete(ctx, rec.Hostname, rec.Type); err != nil {
+			return fmt.Errorf("deleting %s/%s: %w", rec.Hostname, rec.Type, err)
+		}
+	}
+	for _, rec := range updates {
+		if err := p.Update(ctx, rec); err != nil {
+			return fmt.Errorf("updating %s/%s: %w", rec.Hostname, rec.Type, err)
+		}
+	}
+	for _, rec := range changes.Creates {
+		if err := p.Create(ctx, rec); err != nil {
+			return fmt.Errorf("creating %s/%s: %w", rec.Hostname, rec.Type, err)
+		}
+	}
+	return errors.Join(append(deniedDeletes, deniedUpdates...)...)
+}
+
+// filterOwned splits records into those the caller may modify and ownership
+// errors for those it may not, looking up existing records through List.
+func filterOwned(ctx context.Context, p Provider, records []Record) ([]Record, []error, error) {
+	owned := make([]Record, 0, len(records))
+	var denied []error
+	for _, rec := range records {
+		if rec.Owner() == "" {
+			owned = append(owned, rec)
+			continue
+		}
+		existing, err := p.List(ctx, ListFilter{Hostname: rec.Hostname, Type: rec.Type})
+		if err != nil {
+			return nil, nil, fmt.Errorf("checking owner of %s/%s: %w", rec.Hostname, rec.Type, err)
+		}
+		var ownErr error
+		for _, ex := range existing {
+			if ownErr = CheckOwner(rec, ex); ownErr != nil {
+				break
+			}
+		}
+		if ownErr != nil {
+			denied = append(denied, ownErr)
+			continue
+		}
+		owned = append(owned, rec)
+	}
+	return owned, denied, nil
+}
+
+// filterChanged drops the records that the provider already holds unchanged,
+// looking up existing records through List.
+func filterChanged(ctx context.Context, p Provider, records []Record) ([]Record, error) {
+	changed := make([]Record, 0, len(records))
+	for _, rec := range records {
+		existing, err := p.List(ctx, ListFilter{Hostname: rec.Hostname, Type: rec.Type})
+		if err != nil {
+			return nil, fmt.Errorf("checking %s/%s: %w", rec.Hostname, rec.Type, err)
+		}
+		if len(existing) == 1 && Unchanged(rec, existing[0]) {
+			continue
+		}
+		changed = append(changed, rec)
+	}
+	return changed, nil
+}
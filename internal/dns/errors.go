@@ -0,0 +1,59 @@
+package dns
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Providers wrap the errors they return with the sentinel errors of this
+// package, so callers can decide how to handle a failure without knowing the
+// provider: ErrAuthFailed when the DNS server rejects the credentials,
+// ErrRetryable, often together with ErrTimeout or ErrConnection, for failures
+// that may go away on their own, and ErrNonRetryable for requests the DNS
+// server rejects as invalid, which fail the same way until the records or the
+// config change.
+
+// classifiedError is an error that also matches its classes with errors.Is.
+type classifiedError struct {
+	err     error
+	classes []error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return append([]error{e.err}, e.classes...)
+}
+
+// Classify returns err wrapped so that errors.Is also matches each of
+// classes, keeping its message. A nil err stays nil.
+// e.g. Classify(err, ErrTimeout, ErrRetryable)
+func Classify(err error, classes ...error) error {
+	if err == nil || len(classes) == 0 {
+		return err
+	}
+	return &classifiedError{err: err, classes: classes}
+}
+
+// IsRetryable reports whether err may go away when the request is repeated.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRetryable)
+}
+
+// StatusClass returns the sentinel error for an HTTP response status:
+// ErrAuthFailed for 401 and 403, ErrRetryable for 408, 429 and 5xx,
+// ErrNonRetryable for any other 4xx status and nil otherwise.
+func StatusClass(code int) error {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrAuthFailed
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500:
+		return ErrRetryable
+	case code >= 400:
+		return ErrNonRetryable
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package dns
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	base := errors.New("opnsense: request failed")
+	err := Classify(base, ErrTimeout, ErrRetryable)
+	if err.Error() != base.Error() {
+		t.Errorf("expected the message to be kept, got %q", err)
+	}
+	for _, target := range []error{base, ErrTimeout, ErrRetryable} {
+		if !errors.Is(err, target) {
+			t.Errorf("expected the error to match %v", target)
+		}
+	}
+	if errors.Is(err, ErrNonRetryable) {
+		t.Error("expected the error not to match other classes")
+	}
+	if Classify(nil, ErrRetryable) != nil {
+		t.Error("expected a nil error to stay nil")
+	}
+	if Classify(base) != base {
+		t.Error("expected an error without classes to be returned as is")
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[int]error{
+		200: nil,
+		302: nil,
+		400: ErrNonRetryable,
+		401: ErrAuthFailed,
+		403: ErrAuthFailed,
+		404: ErrNonRetryable,
+		408: ErrRetryable,
+		422: ErrNonRetryable,
+		429: ErrRetryable,
+		500: ErrRetryable,
+		503: ErrRetryable,
+	}
+	for code, want := range tests {
+		if got := StatusClass(code); got != want {
+			t.Errorf("StatusClass(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
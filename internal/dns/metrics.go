@@ -0,0 +1,166 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Provider operations used in logs and the provider metrics.
+const (
+	opExists       = "exists"
+	opList         = "list"
+	opCreate       = "create"
+	opUpdate       = "update"
+	opDelete       = "delete"
+	opUpsert       = "upsert"
+	opApplyChanges = "apply_changes"
+	opHealthCheck  = "health_check"
+)
+
+var providerRequestsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_provider_requests_total",
+		Help: "Number of DNS provider calls, by provider, operation and result.",
+	},
+	[]string{"provider", "op", "result"},
+)
+
+var providerRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "yk_dns_provider_request_duration_seconds",
+		Help:    "Duration of DNS provider calls, by provider and operation.",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"provider", "op"},
+)
+
+var providerReconfiguresTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "yk_dns_provider_reconfigures_total",
+		Help: "Number of times a DNS provider reloaded the DNS server after writing records, by provider and result.",
+	},
+	[]string{"provider", "result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(providerRequestsTotal, providerRequestDuration, providerReconfiguresTotal)
+}
+
+// resultLabel returns the result label of a provider call that returned err:
+// "success", or the class of the error.
+func resultLabel(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrNotOwned):
+		return "not_owned"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrAuthFailed):
+		return "auth_failed"
+	case errors.Is(err, ErrNonRetryable):
+		return "non_retryable"
+	case errors.Is(err, ErrRetryable):
+		return "retryable"
+	default:
+		return "error"
+	}
+}
+
+// ReconfigureObserver is implemented by providers that reload the DNS server
+// after writing records, e.g. Unbound's reconfigure on OPNsense.
+type ReconfigureObserver interface {
+	// ObserveReconfigures sets a function called with the result of every
+	// reload.
+	ObserveReconfigures(observe func(err error))
+}
+
+// WithMetrics returns a middleware counting and timing every call to the
+// named provider, and the reloads of providers implementing
+// ReconfigureObserver. Empty change sets are passed on without being counted.
+// Placed innermost, it sees each call the DNS server gets, including every
+// retry.
+func WithMetrics(provider string) Middleware {
+	return func(p Provider) Provider {
+		if ro, ok := p.(ReconfigureObserver); ok {
+			ro.ObserveReconfigures(func(err error) {
+				providerReconfiguresTotal.WithLabelValues(provider, resultLabel(err)).Inc()
+			})
+		}
+		return &instrumented{wrapped: wrapped{p}, provider: provider}
+	}
+}
+
+type instrumented struct {
+	wrapped
+	provider string
+}
+
+// observe records a call of op that started at start and returned err.
+func (m *instrumented) observe(op string, start time.Time, err error) {
+	providerRequestDuration.WithLabelValues(m.provider, op).Observe(time.Since(start).Seconds())
+	providerRequestsTotal.WithLabelValues(m.provider, op, resultLabel(err)).Inc()
+}
+
+func (m *instrumented) Exists(ctx context.Context, hostname, recordType string) (bool, error) {
+	start := time.Now()
+	exists, err := m.Provider.Exists(ctx, hostname, recordType)
+	m.observe(opExists, start, err)
+	return exists, err
+}
+
+func (m *instrumented) List(ctx context.Context, filter ListFilter) ([]Record, error) {
+	start := time.Now()
+	records, err := m.Provider.List(ctx, filter)
+	m.observe(opList, start, err)
+	return records, err
+}
+
+func (m *instrumented) Create(ctx context.Context, record Record) error {
+	start := time.Now()
+	err := m.Provider.Create(ctx, record)
+	m.observe(opCreate, start, err)
+	return err
+}
+
+func (m *instrumented) Update(ctx context.Context, record Record) error {
+	start := time.Now()
+	err := m.Provider.Update(ctx, record)
+	m.observe(opUpdate, start, err)
+	return err
+}
+
+func (m *instrumented) Delete(ctx context.Context, hostname, recordType string) error {
+	start := time.Now()
+	err := m.Provider.Delete(ctx, hostname, recordType)
+	m.observe(opDelete, start, err)
+	return err
+}
+
+func (m *instrumented) Upsert(ctx context.Context, record Record) error {
+	start := time.Now()
+	err := m.Provider.Upsert(ctx, record)
+	m.observe(opUpsert, start, err)
+	return err
+}
+
+func (m *instrumented) ApplyChanges(ctx context.Context, changes ChangeSet) error {
+	if changes.IsEmpty() {
+		return m.Provider.ApplyChanges(ctx, changes)
+	}
+	start := time.Now()
+	err := m.Provider.ApplyChanges(ctx, changes)
+	m.observe(opApplyChanges, start, err)
+	return err
+}
+
+func (m *instrumented) HealthCheck(ctx context.Context) error {
+	start := time.Now()
+	err := m.Provider.HealthCheck(ctx)
+	m.observe(opHealthCheck, start, err)
+	return err
+}
@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestWithMetrics(t *testing.T) {
+	const provider = "metrics-test"
+	ownership := &OwnershipError{Hostname: "app.example.com", Type: "A", Owner: "prod/default/api", Wanted: "prod/default/web"}
+	inner := &flakyProvider{errs: []error{nil, errUnavailable, ownership, Classify(errors.New("denied"), ErrAuthFailed)}}
+	p := WithMetrics(provider)(inner)
+	ctx := context.Background()
+
+	_ = p.Create(ctx, Record{})
+	_ = p.Create(ctx, Record{})
+	_ = p.ApplyChanges(ctx, ChangeSet{Updates: []Record{{}}})
+	_ = p.HealthCheck(ctx)
+	_ = p.ApplyChanges(ctx, ChangeSet{})
+
+	for labels, want := range map[[2]string]float64{
+		{opCreate, "success"}:          1,
+		{opCreate, "retryable"}:        1,
+		{opApplyChanges, "not_owned"}:  1,
+		{opHealthCheck, "auth_failed"}: 1,
+		{opApplyChanges, "success"}:    0,
+		{opCreate, "non_retryable"}:    0,
+	} {
+		if got := testutil.ToFloat64(providerRequestsTotal.WithLabelValues(provider, labels[0], labels[1])); got != want {
+			t.Errorf("expected %v %s calls with result %s, got %v", want, labels[0], labels[1], got)
+		}
+	}
+	if n := testutil.CollectAndCount(providerRequestDuration, "yk_dns_provider_request_duration_seconds"); n < 3 {
+		t.Errorf("expected a latency histogram per operation, got %d series", n)
+	}
+
+	if inner.observe == nil {
+		t.Fatal("expected a reconfigure observer to be set on the provider")
+	}
+	inner.observe(nil)
+	inner.observe(errUnavailable)
+	if got := testutil.ToFloat64(providerReconfiguresTotal.WithLabelValues(provider, "success")); got != 1 {
+		t.Errorf("expected 1 successful reconfigure, got %v", got)
+	}
+	if got := testutil.ToFloat64(providerReconfiguresTotal.WithLabelValues(provider, "retryable")); got != 1 {
+		t.Errorf("expected 1 failed reconfigure, got %v", got)
+	}
+}
@@ -0,0 +1,42 @@
+package dns
+
+// Middleware adds behaviour to a Provider, such as retries or rate limiting,
+// by wrapping it in another Provider.
+type Middleware func(Provider) Provider
+
+// Chain wraps p in the middlewares, the first one outermost: it sees every
+// call first and the result of the others last.
+func Chain(p Provider, middlewares ...Middleware) Provider {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		p = middlewares[i](p)
+	}
+	return p
+}
+
+// noMiddleware returns the provider as it is. Disabled middlewares use it.
+func noMiddleware(p Provider) Provider {
+	return p
+}
+
+// wrapped forwards every call to the wrapped provider, including the optional
+// ZoneSetter, WildcardSupporter and ReconfigureObserver methods. Middlewares
+// embed it and override the calls they handle.
+type wrapped struct {
+	Provider
+}
+
+func (w wrapped) SetZones(z Zones) {
+	if zs, ok := w.Provider.(ZoneSetter); ok {
+		zs.SetZones(z)
+	}
+}
+
+func (w wrapped) SupportsWildcard(recordType string) bool {
+	return SupportsWildcard(w.Provider, recordType)
+}
+
+func (w wrapped) ObserveReconfigures(observe func(err error)) {
+	if ro, ok := w.Provider.(ReconfigureObserver); ok {
+		ro.ObserveReconfigures(observe)
+	}
+}
@@ -0,0 +1,271 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// flakyProvider fails calls with the queued errors before succeeding, and
+// records the calls it gets.
+type flakyProvider struct {
+	Provider
+	errs     []error         // returned by the next calls, in order
+	existing map[string]bool // hostnames Exists reports
+	calls    []string        // method of each call
+	applied  []ChangeSet     // change sets passed to ApplyChanges
+	zones    *Zones          // last zones set
+	wildcard map[string]bool // record types SupportsWildcard reports
+	observe  func(err error) // reconfigure observer set
+}
+
+func (p *flakyProvider) next(call string) error {
+	p.calls = append(p.calls, call)
+	if len(p.errs) == 0 {
+		return nil
+	}
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return err
+}
+
+func (p *flakyProvider) Exists(_ context.Context, hostname, _ string) (bool, error) {
+	return p.existing[hostname], p.next("Exists")
+}
+
+func (p *flakyProvider) List(context.Context, ListFilter) ([]Record, error) {
+	return nil, p.next("List")
+}
+
+func (p *flakyProvider) Create(context.Context, Record) error { return p.next("Create") }
+func (p *flakyProvider) Update(context.Context, Record) error { return p.next("Update") }
+
+func (p *flakyProvider) ApplyChanges(_ context.Context, changes ChangeSet) error {
+	p.applied = append(p.applied, changes)
+	return p.next("ApplyChanges")
+}
+
+func (p *flakyProvider) HealthCheck(context.Context) error { return p.next("HealthCheck") }
+
+func (p *flakyProvider) SetZones(z Zones) { p.zones = &z }
+
+func (p *flakyProvider) SupportsWildcard(recordType string) bool { return p.wildcard[recordType] }
+
+func (p *flakyProvider) ObserveReconfigures(observe func(err error)) { p.observe = observe }
+
+var errUnavailable = Classify(errors.New("returned status 502"), ErrRetryable)
+
+// noSleep replaces the backoff of a retrying provider.
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func TestChain(t *testing.T) {
+	var order []string
+	named := func(name string) Middleware {
+		return func(p Provider) Provider {
+			order = append(order, name)
+			return struct{ wrapped }{wrapped{p}}
+		}
+	}
+	inner := &flakyProvider{wildcard: map[string]bool{"A": true}}
+	p := Chain(inner, named("outer"), named("inner"))
+	if !slices.Equal(order, []string{"inner", "outer"}) {
+		t.Errorf("expected the first middleware to wrap the others, got %v", order)
+	}
+
+	s := NewSwappable(p)
+	zones, _ := NewZones("example.com")
+	s.SetZones(zones)
+	if inner.zones == nil || !slices.Equal(inner.zones.Names(), []string{"example.com"}) {
+		t.Error("expected zones to reach the wrapped provider")
+	}
+	if !s.SupportsWildcard("A") || s.SupportsWildcard("CNAME") {
+		t.Error("expected wildcard support to be asked from the wrapped provider")
+	}
+	if Chain(inner) != Provider(inner) || WithRetry(RetryPolicy{Attempts: 1}, logr.Discard())(inner) != Provider(inner) {
+		t.Error("expected no or disabled middlewares to return the provider as it is")
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	newRetrying := func(inner *flakyProvider) Provider {
+		p := WithRetry(RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}, logr.Discard())(inner)
+		p.(*retrying).sleep = noSleep
+		return p
+	}
+
+	t.Run("retryable until success", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{errUnavailable, errUnavailable}}
+		if _, err := newRetrying(inner).List(ctx, ListFilter{}); err != nil {
+			t.Fatalf("expected the third attempt to succeed, got %v", err)
+		}
+		if len(inner.calls) != 3 {
+			t.Errorf("expected 3 calls, got %v", inner.calls)
+		}
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{errUnavailable, errUnavailable, errUnavailable, nil}}
+		if err := newRetrying(inner).Update(ctx, Record{}); !errors.Is(err, errUnavailable) {
+			t.Fatalf("expected the last error, got %v", err)
+		}
+		if len(inner.calls) != 3 {
+			t.Errorf("expected 3 calls, got %v", inner.calls)
+		}
+	})
+
+	t.Run("non-retryable", func(t *testing.T) {
+		rejected := Classify(errors.New("validation failed"), ErrNonRetryable)
+		inner := &flakyProvider{errs: []error{rejected}}
+		if err := newRetrying(inner).Update(ctx, Record{}); !errors.Is(err, rejected) {
+			t.Fatalf("expected the error, got %v", err)
+		}
+		if len(inner.calls) != 1 {
+			t.Errorf("expected no retry, got %v", inner.calls)
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{errUnavailable}}
+		if err := newRetrying(inner).HealthCheck(ctx); err == nil || len(inner.calls) != 1 {
+			t.Errorf("expected the health check not to be retried, got %v after %v", err, inner.calls)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{errUnavailable, nil}}
+		p := WithRetry(RetryPolicy{Attempts: 3, InitialBackoff: time.Hour}, logr.Discard())(inner)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := p.Update(cancelled, Record{}); !errors.Is(err, errUnavailable) || len(inner.calls) != 1 {
+			t.Errorf("expected the first error once the context is done, got %v after %v", err, inner.calls)
+		}
+	})
+
+	t.Run("created records are retried as updates", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{errUnavailable}, existing: map[string]bool{"a.example.com": true}}
+		changes := ChangeSet{
+			Creates: []Record{{Hostname: "a.example.com", Type: "A"}, {Hostname: "b.example.com", Type: "A"}},
+			Updates: []Record{{Hostname: "c.example.com", Type: "A"}},
+		}
+		if err := newRetrying(inner).ApplyChanges(ctx, changes); err != nil {
+			t.Fatal(err)
+		}
+		if len(inner.applied) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", len(inner.applied))
+		}
+		retried := inner.applied[1]
+		if len(retried.Creates) != 1 || retried.Creates[0].Hostname != "b.example.com" ||
+			len(retried.Updates) != 2 || retried.Updates[1].Hostname != "a.example.com" {
+			t.Errorf("expected the existing record to be retried as an update, got %+v", retried)
+		}
+		if len(changes.Updates) != 1 {
+			t.Error("expected the caller's change set to be left alone")
+		}
+
+		inner = &flakyProvider{errs: []error{errUnavailable}, existing: map[string]bool{"a.example.com": true}}
+		if err := newRetrying(inner).Create(ctx, Record{Hostname: "a.example.com", Type: "A"}); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(inner.calls, []string{"Create", "Exists", "Update"}) {
+			t.Errorf("expected the retry to update the created record, got %v", inner.calls)
+		}
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for n, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for range 20 {
+			if got := policy.backoff(n); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", n, got, want/2, want)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).backoff(3); got != 0 {
+		t.Errorf("expected no backoff without an initial backoff, got %s", got)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	inner := &flakyProvider{}
+	p := WithRateLimit(RateLimit{WritesPerSecond: 0.001, Burst: 2})(inner)
+	ctx := context.Background()
+
+	for range 2 {
+		if err := p.Create(ctx, Record{}); err != nil {
+			t.Fatalf("expected the burst to pass, got %v", err)
+		}
+	}
+	if err := p.ApplyChanges(ctx, ChangeSet{}); err != nil {
+		t.Errorf("expected an empty change set not to take a token, got %v", err)
+	}
+	if _, err := p.List(ctx, ListFilter{}); err != nil {
+		t.Errorf("expected reads not to be limited, got %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.ApplyChanges(short, ChangeSet{Creates: []Record{{}}}); err == nil {
+		t.Error("expected a write beyond the burst to wait past the deadline")
+	}
+	if !slices.Equal(inner.calls, []string{"Create", "Create", "ApplyChanges", "List"}) {
+		t.Errorf("unexpected calls %v", inner.calls)
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	inner := &flakyProvider{}
+	p := WithCircuitBreaker(BreakerPolicy{FailureThreshold: 2, OpenDuration: time.Minute}, logr.Discard())(inner)
+	now := time.Now()
+	p.(*breaker).now = func() time.Time { return now }
+	ctx := context.Background()
+	rejected := Classify(errors.New("validation failed"), ErrNonRetryable)
+
+	// Errors the DNS server answered with reset the count.
+	inner.errs = []error{errUnavailable, rejected, errUnavailable}
+	for range 3 {
+		_ = p.Update(ctx, Record{})
+	}
+	if err := p.Update(ctx, Record{}); err != nil {
+		t.Fatalf("expected the circuit to stay closed, got %v", err)
+	}
+
+	inner.errs = []error{errUnavailable, errUnavailable}
+	for range 2 {
+		_ = p.Update(ctx, Record{})
+	}
+	calls := len(inner.calls)
+	err := p.Update(ctx, Record{})
+	if !errors.Is(err, ErrCircuitOpen) || !IsRetryable(err) {
+		t.Fatalf("expected a retryable ErrCircuitOpen, got %v", err)
+	}
+	if len(inner.calls) != calls {
+		t.Error("expected an open circuit not to call the provider")
+	}
+	if err := p.HealthCheck(ctx); err != nil {
+		t.Errorf("expected the health check to reach the provider, got %v", err)
+	}
+
+	// A failed trial call opens the circuit again.
+	now = now.Add(time.Minute)
+	inner.errs = []error{errUnavailable}
+	if err := p.Update(ctx, Record{}); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the trial call to reach the provider, got %v", err)
+	}
+	if err := p.Update(ctx, Record{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to open again, got %v", err)
+	}
+
+	// A successful trial call closes it.
+	now = now.Add(time.Minute)
+	if err := p.Update(ctx, Record{}); err != nil {
+		t.Fatalf("expected the trial call to succeed, got %v", err)
+	}
+	if err := p.Update(ctx, Record{}); err != nil {
+		t.Errorf("expected the circuit to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package opnsense
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// CNAME records are stored as Unbound host aliases. An alias answers with the
+// records of the host override it is attached to, so the CNAME target must be
+// a host override on the same firewall.
+const typeCNAME = "CNAME"
+
+// isAlias reports whether records of this type are stored as host aliases.
+func isAlias(recordType string) bool {
+	return strings.EqualFold(recordType, typeCNAME)
+}
+
+// aliasRow represents a single host alias row from the search response. Host
+// is the FQDN of the host override the alias is attached to.
+type aliasRow struct {
+	UUID        string `json:"uuid"`
+	Enabled     string `json:"enabled"`
+	Host        string `json:"host"`
+	Hostname    string `json:"hostname"`
+	Domain      string `json:"domain"`
+	Description string `json:"description"`
+}
+
+// toRecord converts a host alias row into a CNAME dns.Record.
+func (row aliasRow) toRecord() dns.Record {
+	return hostRow{
+		UUID:        row.UUID,
+		Enabled:     row.Enabled,
+		Hostname:    row.Hostname,
+		Domain:      row.Domain,
+		RR:          typeCNAME,
+		Server:      row.Host,
+		Description: row.Description,
+	}.toRecord()
+}
+
+// matchAlias returns the first alias row for hostname.
+func matchAlias(rows []aliasRow, fqdn string) (aliasRow, bool) {
+	fqdn = dns.CanonicalHostname(fqdn)
+	for _, row := range rows {
+		if dns.CanonicalHostname(rowFQDN(row.Hostname, row.Domain)) == fqdn {
+			return row, true
+		}
+	}
+	return aliasRow{}, false
+}
+
+// findAlias searches for an existing host alias for hostname.
+func (p *Provider) findAlias(ctx context.Context, fqdn string) (aliasRow, bool, error) {
+	rows, err := p.aliases(ctx)
+	if err != nil {
+		return aliasRow{}, false, err
+	}
+	row, ok := matchAlias(rows, fqdn)
+	return row, ok, nil
+}
+
+// matchTarget returns the UUID of the host override a CNAME to target attaches
+// to, preferring an A override over other record types.
+func matchTarget(idx *overrideIndex, target string) (string, bool) {
+	if matched := idx.match(target, "A"); len(matched) > 0 {
+		return matched[0].UUID, true
+	}
+	if named := idx.named(target); len(named) > 0 {
+		return named[0].UUID, true
+	}
+	return "", false
+}
+
+// aliasTarget returns the target of a CNAME record, which has a single value.
+func aliasTarget(record dns.Record) string {
+	if len(record.Values) == 0 {
+		return ""
+	}
+	return record.Values[0]
+}
+
+// targetUUID returns the UUID of the host override for a CNAME record's target.
+func targetUUID(idx *overrideIndex, record dns.Record) (string, error) {
+	if len(record.Values) != 1 {
+		return "", dns.Classify(fmt.Errorf("opnsense: CNAME %s must have exactly one target, got %d", record.Hostname, len(record.Values)), dns.ErrNonRetryable)
+	}
+	uuid, ok := matchTarget(idx, aliasTarget(record))
+	if !ok {
+		return "", fmt.Errorf("opnsense: CNAME target %s of %s has no host override", aliasTarget(record), record.Hostname)
+	}
+	return uuid, nil
+}
+
+// aliasUnchanged reports whether an alias row already points to the CNAME
+// record's target with its description, so the write can be skipped.
+func aliasUnchanged(row aliasRow, record dns.Record) bool {
+	return row.Enabled == "1" &&
+		strings.EqualFold(strings.TrimSuffix(row.Host, "."), strings.TrimSuffix(aliasTarget(record), ".")) &&
+		row.Description == recordDescription(record)
+}
+
+// buildAliasBody creates the JSON body for add/set host alias calls, naming
+// the alias host in domain.
+func buildAliasBody(host, domain string, record dns.Record, hostUUID string) map[string]interface{} {
+	return map[string]interface{}{
+		"alias": map[string]string{
+			"enabled":     "1",
+			"host":        hostUUID,
+			"hostname":    host,
+			"domain":      domain,
+			"description": recordDescription(record),
+		},
+	}
+}
+
+// addAlias adds a host alias without applying the configuration.
+func (p *Provider) addAlias(ctx context.Context, hostUUID string, record dns.Record) error {
+	body, err := p.aliasBody(record, hostUUID)
+	if err != nil {
+		return err
+	}
+	uuid, err := p.save(ctx, "addHostAlias", "unbound/settings/addHostAlias", body)
+	if err != nil {
+		return err
+	}
+	p.log.V(1).Info("alias created", "uuid", uuid)
+	return nil
+}
+
+// setAlias replaces the host alias with the given UUID without applying the configuration.
+func (p *Provider) setAlias(ctx context.Context, uuid, hostUUID string, record dns.Record) error {
+	body, err := p.aliasBody(record, hostUUID)
+	if err != nil {
+		return err
+	}
+	if _, err := p.save(ctx, "setHostAlias", "unbound/settings/setHostAlias/"+uuid, body); err != nil {
+		return err
+	}
+	p.log.V(1).Info("alias updated", "uuid", uuid)
+	return nil
+}
+
+// delAlias removes the host alias with the given UUID without applying the configuration.
+func (p *Provider) delAlias(ctx context.Context, uuid string) error {
+	return p.remove(ctx, "delHostAlias", uuid)
+}
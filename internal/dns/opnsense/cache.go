@@ -0,0 +1,180 @@
+package opnsense
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Reading the host override table is the most expensive call the provider
+// makes, since every lookup needs the whole table. The provider keeps the last
+// table it read, indexed by hostname, domain and record type, and reuses it
+// until cache_ttl expires. Every write through the API drops it, so the
+// provider always sees its own changes; changes made in the OPNsense UI show up
+// once the cache expires.
+
+// defaultCacheTTL is how long the host override and alias tables are reused
+// when cache_ttl is not set.
+const defaultCacheTTL = 10 * time.Second
+
+// searchPageSize is the number of rows requested per search call.
+const searchPageSize = 500
+
+// overrideKey identifies the host overrides of an FQDN and record type. The
+// FQDN is canonical, so rows are found whichever way their hostname and domain
+// fields split it.
+type overrideKey struct {
+	fqdn, rr string
+}
+
+// keyFor returns the key of an FQDN and record type. An empty record type
+// stands for every type.
+func keyFor(fqdn, recordType string) overrideKey {
+	return overrideKey{dns.CanonicalHostname(fqdn), strings.ToUpper(recordType)}
+}
+
+// overrideIndex is the host override table indexed for lookups by hostname and
+// record type. It is shared between callers and must not be modified.
+type overrideIndex struct {
+	rows   []hostRow
+	byKey  map[overrideKey][]hostRow // by FQDN and record type
+	byName map[overrideKey][]hostRow // by FQDN, for any record type
+}
+
+// newOverrideIndex indexes host override rows, keeping their order.
+func newOverrideIndex(rows []hostRow) *overrideIndex {
+	idx := &overrideIndex{
+		rows:   rows,
+		byKey:  make(map[overrideKey][]hostRow, len(rows)),
+		byName: make(map[overrideKey][]hostRow, len(rows)),
+	}
+	for _, row := range rows {
+		key := keyFor(rowFQDN(row.Hostname, row.Domain), row.RR)
+		idx.byKey[key] = append(idx.byKey[key], row)
+		key.rr = ""
+		idx.byName[key] = append(idx.byName[key], row)
+	}
+	return idx
+}
+
+// match returns the rows matching hostname and record type.
+func (idx *overrideIndex) match(fqdn, recordType string) []hostRow {
+	return idx.byKey[keyFor(fqdn, recordType)]
+}
+
+// named returns the rows for hostname, of any record type.
+func (idx *overrideIndex) named(fqdn string) []hostRow {
+	return idx.byName[keyFor(fqdn, "")]
+}
+
+// cached holds a value read from the API until it expires or is invalidated.
+// A ttl of 0 disables caching.
+type cached[T any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	value   T
+	expires time.Time
+	valid   bool
+}
+
+// get returns the cached value while it is fresh, or calls fetch to replace
+// it. Concurrent callers wait for a single fetch.
+func (c *cached[T]) get(fetch func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.valid && time.Now().Before(c.expires) {
+		return c.value, nil
+	}
+	value, err := fetch()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if c.ttl > 0 {
+		c.value, c.expires, c.valid = value, time.Now().Add(c.ttl), true
+	}
+	return value, nil
+}
+
+// invalidate drops the cached value, so the next get fetches it again.
+func (c *cached[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero T
+	c.value, c.valid = zero, false
+}
+
+// overrides returns the indexed host override table, from the cache while it
+// is fresh.
+func (p *Provider) overrides(ctx context.Context) (*overrideIndex, error) {
+	return p.overrideCache.get(func() (*overrideIndex, error) {
+		rows, err := search[hostRow](ctx, p, "searchHostOverride")
+		if err != nil {
+			return nil, err
+		}
+		return newOverrideIndex(rows), nil
+	})
+}
+
+// aliases returns the host alias table, from the cache while it is fresh.
+func (p *Provider) aliases(ctx context.Context) ([]aliasRow, error) {
+	return p.aliasCache.get(func() ([]aliasRow, error) {
+		return search[aliasRow](ctx, p, "searchHostAlias")
+	})
+}
+
+// invalidate drops the cached tables after a write.
+func (p *Provider) invalidate() {
+	p.overrideCache.invalidate()
+	p.aliasCache.invalidate()
+}
+
+// searchResponse is the shape returned by search endpoints.
+type searchResponse[T any] struct {
+	Rows  []T `json:"rows"`
+	Total int `json:"total"`
+}
+
+// search fetches every row of a search endpoint such as searchHostOverride,
+// one page at a time until a page is not full. The total the server reports
+// is not trusted, as it can disagree with the rows it returns. Servers that
+// ignore the paging parameters return all rows at once, in a longer page.
+func search[T any](ctx context.Context, p *Provider, endpoint string) ([]T, error) {
+	var rows []T
+	for page := 1; ; page++ {
+		sr, err := searchPage[T](ctx, p, endpoint, page)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, sr.Rows...)
+		if len(sr.Rows) != searchPageSize {
+			p.log.V(1).Info("searched table", "endpoint", endpoint, "rows", len(rows), "pages", page)
+			return rows, nil
+		}
+	}
+}
+
+// searchPage fetches one page of a search endpoint.
+func searchPage[T any](ctx context.Context, p *Provider, endpoint string, page int) (searchResponse[T], error) {
+	var sr searchResponse[T]
+	path := fmt.Sprintf("unbound/settings/%s?current=%d&rowCount=%d", endpoint, page, searchPageSize)
+	resp, err := p.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return sr, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return sr, statusError(endpoint, resp.StatusCode, "")
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
+		return sr, fmt.Errorf("opnsense: decode %s response: %w", endpoint, err)
+	}
+	return sr, nil
+}
@@ -0,0 +1,57 @@
+package opnsense
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverrideIndex(t *testing.T) {
+	idx := newOverrideIndex([]hostRow{
+		{UUID: "1", Hostname: "app", Domain: "example.com", RR: "A", Server: "10.0.0.1"},
+		{UUID: "2", Hostname: "App", Domain: "Example.com", RR: "a", Server: "10.0.0.2"},
+		{UUID: "3", Hostname: "app", Domain: "example.com", RR: "AAAA", Server: "fd00::1"},
+		{UUID: "4", Hostname: "db", Domain: "example.com", RR: "AAAA", Server: "fd00::2"},
+	})
+
+	if got := idx.match("APP.example.com.", "A"); len(got) != 2 || got[0].UUID != "1" || got[1].UUID != "2" {
+		t.Errorf("expected rows 1 and 2 in table order, got %+v", got)
+	}
+	if got := idx.match("app.example.com", "CNAME"); len(got) != 0 {
+		t.Errorf("expected no CNAME rows, got %+v", got)
+	}
+	if got := idx.named("app.example.com"); len(got) != 3 {
+		t.Errorf("expected 3 rows of any type, got %d", len(got))
+	}
+	if uuid, ok := matchTarget(idx, "db.example.com"); !ok || uuid != "4" {
+		t.Errorf("expected the AAAA override as CNAME target, got %q, %v", uuid, ok)
+	}
+}
+
+func TestCached(t *testing.T) {
+	fetches := 0
+	fetch := func() (int, error) {
+		fetches++
+		return fetches, nil
+	}
+
+	c := &cached[int]{ttl: time.Hour}
+	c.get(fetch)
+	if v, _ := c.get(fetch); v != 1 || fetches != 1 {
+		t.Errorf("expected the cached value, got %d after %d fetches", v, fetches)
+	}
+	c.invalidate()
+	if v, _ := c.get(fetch); v != 2 {
+		t.Errorf("expected a fetch after invalidate, got %d", v)
+	}
+
+	c.expires = time.Now().Add(-time.Second)
+	if v, _ := c.get(fetch); v != 3 {
+		t.Errorf("expected a fetch once expired, got %d", v)
+	}
+
+	disabled := &cached[int]{}
+	disabled.get(fetch)
+	if v, _ := disabled.get(fetch); v != 5 {
+		t.Errorf("expected every get to fetch with a ttl of 0, got %d", v)
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -30,11 +35,21 @@ type Provider struct {
 	defaultTTL int
 	client     *http.Client
 	log        logr.Logger
+
+	mu           sync.Mutex      // guards the TTL support fields, zones and reconfigured
+	ttlChecked   bool            // whether ttlSupported has been detected
+	ttlSupported bool            // whether host overrides have a ttl field
+	zones        dns.Zones       // Unbound zones hostnames are split on
+	reconfigured func(err error) // called with the result of every reconfigure, may be nil
+
+	overrideCache cached[*overrideIndex]
+	aliasCache    cached[[]aliasRow]
 }
 
 // New creates an OPNsense DNS provider from the given settings map.
 // Required settings: base_url, api_key, api_secret.
-// Optional settings: default_ttl (default 300), skip_tls_verify (default false).
+// Optional settings: default_ttl (default 300), skip_tls_verify (default false),
+// cache_ttl (default 10s, 0 disables the cache).
 func New(log logr.Logger, settings map[string]string) (*Provider, error) {
 	baseURL := settings["base_url"]
 	if baseURL == "" {
@@ -55,22 +70,40 @@ func New(log logr.Logger, settings map[string]string) (*Provider, error) {
 		if err != nil {
 			return nil, fmt.Errorf("opnsense: invalid default_ttl %q: %w", v, err)
 		}
+		if parsed <= 0 {
+			return nil, fmt.Errorf("opnsense: default_ttl must be positive, got %d", parsed)
+		}
 		defaultTTL = parsed
 	}
 
+	cacheTTL := defaultCacheTTL
+	if v := settings["cache_ttl"]; v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("opnsense: invalid cache_ttl %q: %w", v, err)
+		}
+		if parsed < 0 {
+			return nil, fmt.Errorf("opnsense: cache_ttl must not be negative, got %s", parsed)
+		}
+		cacheTTL = parsed
+	}
+
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	if v := settings["skip_tls_verify"]; v == "true" {
 		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
-	return &Provider{
+	p := &Provider{
 		baseURL:    baseURL,
 		apiKey:     apiKey,
 		apiSecret:  apiSecret,
 		defaultTTL: defaultTTL,
 		client:     &http.Client{Transport: transport},
 		log:        log,
-	}, nil
+	}
+	p.overrideCache.ttl = cacheTTL
+	p.aliasCache.ttl = cacheTTL
+	return p, nil
 }
 
 // doRequest builds and executes an HTTP request against the OPNsense API.
@@ -102,29 +135,44 @@ func (p *Provider) doRequest(ctx context.Context, method, path string, body inte
 	return resp, nil
 }
 
-// classifyError wraps HTTP errors with meaningful types.
+// classifyError wraps HTTP errors with meaningful types. Failures to reach
+// the API are retryable; a cancelled context is returned unclassified.
 func (p *Provider) classifyError(method, path string, err error) error {
 	errMsg := err.Error()
 
-	var Wrapf = func(msg string) error {
-		return fmt.Errorf("opnsense: %s %s: %s: %s", method, path, msg, errMsg)
+	var Wrapf = func(msg string, classes ...error) error {
+		return dns.Classify(fmt.Errorf("opnsense: %s %s: %s: %w", method, path, msg, err), classes...)
 	}
 
+	var netErr net.Error
 	switch {
+	case errors.Is(err, context.Canceled):
+		return Wrapf("request cancelled")
 	case strings.Contains(errMsg, "connection refused"):
-		return Wrapf("connection refused")
+		return Wrapf("connection refused", dns.ErrConnection, dns.ErrRetryable)
 	case strings.Contains(errMsg, "no such host"):
-		return Wrapf("dns resolution failed")
-	case strings.Contains(errMsg, "timeout"):
-		return Wrapf("timeout")
+		return Wrapf("dns resolution failed", dns.ErrConnection, dns.ErrRetryable)
+	case errors.As(err, &netErr) && netErr.Timeout(), strings.Contains(errMsg, "timeout"):
+		return Wrapf("timeout", dns.ErrTimeout, dns.ErrRetryable)
 	case strings.Contains(errMsg, "no route to host"):
-		return Wrapf("no route to host")
+		return Wrapf("no route to host", dns.ErrConnection, dns.ErrRetryable)
 	default:
-		return Wrapf("request failed")
+		return Wrapf("request failed", dns.ErrRetryable)
+	}
+}
+
+// statusError reports an unexpected HTTP status returned by endpoint,
+// classified by dns.StatusClass. detail, if not empty, is appended.
+func statusError(endpoint string, code int, detail string) error {
+	msg := fmt.Sprintf("opnsense: %s returned status %d", endpoint, code)
+	if detail != "" {
+		msg += ": " + detail
 	}
+	return dns.Classify(errors.New(msg), dns.StatusClass(code))
 }
 
-// HealthCheck verifies the OPNsense API is reachable and credentials are valid.
+// HealthCheck verifies the OPNsense API is reachable and credentials are
+// valid, and detects whether host overrides support TTLs.
 func (p *Provider) HealthCheck(ctx context.Context) error {
 	resp, err := p.doRequest(ctx, http.MethodGet, "unbound/settings/searchHostOverride", nil)
 	if err != nil {
@@ -134,14 +182,23 @@ func (p *Provider) HealthCheck(ctx context.Context) error {
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		return nil
+		_, err := p.detectTTL(ctx)
+		return err
 	case http.StatusUnauthorized, http.StatusForbidden:
-		return fmt.Errorf("opnsense: authentication failed (HTTP %d)", resp.StatusCode)
+		return fmt.Errorf("opnsense: %w (HTTP %d)", dns.ErrAuthFailed, resp.StatusCode)
 	default:
-		return fmt.Errorf("opnsense: health check failed (HTTP %d)", resp.StatusCode)
+		return dns.Classify(fmt.Errorf("opnsense: health check failed (HTTP %d)", resp.StatusCode), dns.StatusClass(resp.StatusCode))
 	}
 }
 
+// ObserveReconfigures sets a function called with the result of every
+// reconfigure, implementing dns.ReconfigureObserver.
+func (p *Provider) ObserveReconfigures(observe func(err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reconfigured = observe
+}
+
 // reconfigure tells OPNsense to apply DNS changes.
 func (p *Provider) reconfigure(ctx context.Context) error {
 	resp, err := p.doRequest(ctx, http.MethodPost, "unbound/service/reconfigure", struct{}{})
@@ -151,7 +208,7 @@ func (p *Provider) reconfigure(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("opnsense: reconfigure returned status %d", resp.StatusCode)
+		return statusError("reconfigure", resp.StatusCode, "")
 	}
 
 	var result struct {
@@ -164,190 +221,352 @@ func (p *Provider) reconfigure(ctx context.Context) error {
 	return nil
 }
 
-// searchResponse is the shape returned by searchHostOverride.
-type searchResponse struct {
-	Rows []hostRow `json:"rows"`
-}
-
 // hostRow represents a single host override row from the search response.
 type hostRow struct {
-	UUID     string `json:"uuid"`
-	Enabled  string `json:"enabled"`
-	Hostname string `json:"hostname"`
-	Domain   string `json:"domain"`
-	RR       string `json:"rr"`
-	Server   string `json:"server"`
+	UUID        string `json:"uuid"`
+	Enabled     string `json:"enabled"`
+	Hostname    string `json:"hostname"`
+	Domain      string `json:"domain"`
+	RR          string `json:"rr"`
+	Server      string `json:"server"`
+	TTL         string `json:"ttl"` // empty on versions without TTL support
+	Description string `json:"description"`
 }
 
-// findOverride searches for an existing host override matching hostname and record type.
-// Returns the UUID if found, or empty string if not.
-func (p *Provider) findOverride(ctx context.Context, fqdn, recordType string) (string, error) {
-	resp, err := p.doRequest(ctx, http.MethodGet, "unbound/settings/searchHostOverride", nil)
-	if err != nil {
-		return "", err
+// rowFQDN joins the hostname and domain fields of a row. An empty or "@"
+// hostname stands for the domain itself.
+func rowFQDN(hostname, domain string) string {
+	if hostname == "" || hostname == "@" {
+		return domain
 	}
-	defer resp.Body.Close()
+	return hostname + "." + domain
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("opnsense: searchHostOverride returned status %d", resp.StatusCode)
+// toRecord converts a host override row into a dns.Record.
+func (row hostRow) toRecord() dns.Record {
+	fqdn := rowFQDN(row.Hostname, row.Domain)
+	description, owner := parseDescription(row.Description)
+	meta := map[string]string{
+		"uuid":        row.UUID,
+		"enabled":     row.Enabled,
+		"description": description,
+	}
+	if owner != "" {
+		meta[dns.MetaOwner] = owner
+	}
+	return dns.Record{
+		Hostname: fqdn,
+		Type:     row.RR,
+		Values:   []string{row.Server},
+		TTL:      parseTTL(row.TTL),
+		Meta:     meta,
 	}
+}
+
+// ownerTag marks the owner ID inside a host override description, since
+// Unbound overrides have no dedicated field for it.
+const ownerTag = " [owner="
 
-	var sr searchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
-		return "", fmt.Errorf("opnsense: decode search response: %w", err)
+// encodeDescription appends the owner tag to a description.
+// e.g. ("managed by yk-dns-manager", "prod/default/web") → "managed by yk-dns-manager [owner=prod/default/web]"
+func encodeDescription(description, owner string) string {
+	if owner == "" {
+		return description
 	}
+	return description + ownerTag + owner + "]"
+}
 
-	host, domain := dns.SplitHostname(fqdn)
-	for _, row := range sr.Rows {
-		if strings.EqualFold(row.Hostname, host) &&
-			strings.EqualFold(row.Domain, domain) &&
-			strings.EqualFold(row.RR, recordType) {
-			return row.UUID, nil
-		}
+// parseDescription splits a description written by encodeDescription back
+// into the description and owner ID.
+func parseDescription(raw string) (description, owner string) {
+	idx := strings.LastIndex(raw, ownerTag)
+	if idx < 0 || !strings.HasSuffix(raw, "]") {
+		return raw, ""
 	}
-	return "", nil
+	return raw[:idx], raw[idx+len(ownerTag) : len(raw)-1]
 }
 
-// buildHostBody creates the JSON body for add/set host override calls.
-func buildHostBody(record dns.Record) map[string]interface{} {
-	host, domain := dns.SplitHostname(record.Hostname)
-	description := ""
-	if record.Meta != nil {
-		description = record.Meta["description"]
+// groupRecords converts host override rows into records, merging the rows of
+// a hostname and record type into a single record with one value per row.
+// The remaining fields are taken from the first row.
+func groupRecords(rows []hostRow) []dns.Record {
+	var records []dns.Record
+	index := make(map[string]int)
+	for _, row := range rows {
+		rec := row.toRecord()
+		key := strings.ToLower(rec.Hostname) + "|" + strings.ToUpper(rec.Type)
+		if i, ok := index[key]; ok {
+			records[i].Values = append(records[i].Values, row.Server)
+			continue
+		}
+		index[key] = len(records)
+		records = append(records, rec)
 	}
-	return map[string]interface{}{
-		"host": map[string]string{
-			"enabled":     "1",
-			"hostname":    host,
-			"domain":      domain,
-			"rr":          record.Type,
-			"server":      record.Value,
-			"description": description,
-			"mxprio":      "",
-			"mx":          "",
-		},
+	return records
+}
+
+// checkOwner verifies that rec may modify every one of the existing rows.
+func checkOwner(rec dns.Record, rows []hostRow) error {
+	for _, row := range rows {
+		if err := dns.CheckOwner(rec, row.toRecord()); err != nil {
+			return fmt.Errorf("opnsense: %w", err)
+		}
 	}
+	return nil
 }
 
-// Exists checks whether a DNS host override exists for the given hostname and record type.
-func (p *Provider) Exists(ctx context.Context, hostname, recordType string) (bool, error) {
-	p.log.V(1).Info("checking if record exists", "hostname", hostname, "type", recordType)
-	uuid, err := p.findOverride(ctx, hostname, recordType)
-	if err != nil {
-		return false, err
+// recordDescription returns the description written for a record, with its
+// owner tag.
+func recordDescription(record dns.Record) string {
+	if record.Meta == nil {
+		return ""
 	}
-	return uuid != "", nil
+	return encodeDescription(record.Meta["description"], record.Owner())
 }
 
-// Create adds a new DNS host override.
-func (p *Provider) Create(ctx context.Context, record dns.Record) error {
-	p.log.V(1).Info("creating record", "hostname", record.Hostname, "type", record.Type, "value", record.Value)
+// overrideUnchanged reports whether a host override row already holds what
+// writing one of the record's values with the given TTL would, so the write
+// can be skipped.
+func overrideUnchanged(row hostRow, record dns.Record, ttl int) bool {
+	return row.Enabled == "1" &&
+		row.Description == recordDescription(record) &&
+		(ttl == 0 || parseTTL(row.TTL) == ttl)
+}
+
+// buildHostBody creates the JSON body for add/set host override calls,
+// holding one of the record's values under host in domain. A ttl of 0 leaves
+// the field out for versions that don't have it.
+func buildHostBody(host, domain string, record dns.Record, value string, ttl int) map[string]interface{} {
+	fields := map[string]string{
+		"enabled":     "1",
+		"hostname":    host,
+		"domain":      domain,
+		"rr":          record.Type,
+		"server":      value,
+		"description": recordDescription(record),
+		"mxprio":      "",
+		"mx":          "",
+	}
+	if ttl > 0 {
+		fields["ttl"] = strconv.Itoa(ttl)
+	}
+	return map[string]interface{}{"host": fields}
+}
+
+// settingsResponse is the response of a settings call such as
+// addHostOverride. Validations holds the error of each rejected field.
+type settingsResponse struct {
+	Result      string         `json:"result"`
+	UUID        string         `json:"uuid"`
+	Validations map[string]any `json:"validations"`
+}
+
+// unexpected returns the error for a response with an unexpected result,
+// listing its validation errors. OPNsense rejects the same request the same
+// way, so it is not retryable.
+func (r settingsResponse) unexpected(endpoint string) error {
+	msg := fmt.Sprintf("opnsense: %s unexpected result: %s", endpoint, r.Result)
+	if len(r.Validations) > 0 {
+		fields := make([]string, 0, len(r.Validations))
+		for field, v := range r.Validations {
+			fields = append(fields, fmt.Sprintf("%s: %v", field, v))
+		}
+		slices.Sort(fields)
+		msg += " (" + strings.Join(fields, "; ") + ")"
+	}
+	return dns.Classify(errors.New(msg), dns.ErrNonRetryable)
+}
 
-	body := buildHostBody(record)
-	resp, err := p.doRequest(ctx, http.MethodPost, "unbound/settings/addHostOverride", body)
+// post sends a settings call such as addHostOverride and returns its
+// response. The cached tables are dropped, whether or not the call succeeded.
+func (p *Provider) post(ctx context.Context, endpoint, path string, body interface{}) (settingsResponse, error) {
+	defer p.invalidate()
+	var response settingsResponse
+	resp, err := p.doRequest(ctx, http.MethodPost, path, body)
 	if err != nil {
-		return err
+		return response, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("opnsense: addHostOverride returned status %d: %s", resp.StatusCode, string(respBody))
+		return response, statusError(endpoint, resp.StatusCode, string(respBody))
 	}
 
-	var result struct {
-		Result string `json:"result"`
-		UUID   string `json:"uuid"`
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return response, fmt.Errorf("opnsense: decode %s response: %w", endpoint, err)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("opnsense: decode addHostOverride response: %w", err)
+	return response, nil
+}
+
+// save sends an add or set call and checks that the item was saved.
+func (p *Provider) save(ctx context.Context, endpoint, path string, body interface{}) (string, error) {
+	response, err := p.post(ctx, endpoint, path, body)
+	if err != nil {
+		return "", err
 	}
-	if result.Result != "saved" {
-		return fmt.Errorf("opnsense: addHostOverride unexpected result: %s", result.Result)
+	if response.Result != "saved" {
+		return "", response.unexpected(endpoint)
 	}
-
-	p.log.V(1).Info("record created", "uuid", result.UUID)
-	return p.reconfigure(ctx)
+	return response.UUID, nil
 }
 
-// Update modifies an existing DNS host override.
-func (p *Provider) Update(ctx context.Context, record dns.Record) error {
-	p.log.V(1).Info("updating record", "hostname", record.Hostname, "type", record.Type, "value", record.Value)
-
-	uuid, err := p.findOverride(ctx, record.Hostname, record.Type)
+// remove sends a del call, treating items that are already gone as deleted.
+func (p *Provider) remove(ctx context.Context, endpoint, uuid string) error {
+	response, err := p.post(ctx, endpoint, fmt.Sprintf("unbound/settings/%s/%s", endpoint, uuid), struct{}{})
 	if err != nil {
 		return err
 	}
-	if uuid == "" {
-		return fmt.Errorf("opnsense: no existing override found for %s/%s", record.Hostname, record.Type)
+	switch response.Result {
+	case "deleted":
+		p.log.V(1).Info("record deleted", "uuid", uuid)
+	case "not found":
+		p.log.V(1).Info("record already deleted", "uuid", uuid)
+	default:
+		return response.unexpected(endpoint)
 	}
+	return nil
+}
 
-	body := buildHostBody(record)
-	resp, err := p.doRequest(ctx, http.MethodPost, fmt.Sprintf("unbound/settings/setHostOverride/%s", uuid), body)
+// addOverride adds a host override for one value of a record without applying the configuration.
+func (p *Provider) addOverride(ctx context.Context, record dns.Record, value string) error {
+	uuid, err := p.save(ctx, "addHostOverride", "unbound/settings/addHostOverride", p.hostBody(record, value))
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	p.log.V(1).Info("record created", "uuid", uuid)
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("opnsense: setHostOverride returned status %d: %s", resp.StatusCode, string(respBody))
+// setOverride replaces the host override with the given UUID by one value of
+// a record without applying the configuration.
+func (p *Provider) setOverride(ctx context.Context, uuid string, record dns.Record, value string) error {
+	if _, err := p.save(ctx, "setHostOverride", "unbound/settings/setHostOverride/"+uuid, p.hostBody(record, value)); err != nil {
+		return err
 	}
+	p.log.V(1).Info("record updated", "uuid", uuid)
+	return nil
+}
 
-	var result struct {
-		Result string `json:"result"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("opnsense: decode setHostOverride response: %w", err)
+// delOverride removes the host override with the given UUID without applying the configuration.
+func (p *Provider) delOverride(ctx context.Context, uuid string) error {
+	return p.remove(ctx, "delHostOverride", uuid)
+}
+
+// syncOverrides makes the host overrides for a record's hostname and type,
+// given as existing, hold exactly the record's values: rows holding a wanted
+// value are rewritten unless they are unchanged, rows holding other values or
+// duplicates are deleted and missing values are added. It returns the number
+// of rows written.
+func (p *Provider) syncOverrides(ctx context.Context, existing []hostRow, record dns.Record) (int, error) {
+	written := 0
+	ttl := p.recordTTL(record)
+	kept := make(map[string]bool, len(record.Values))
+	for _, row := range existing {
+		value := strings.ToLower(row.Server)
+		var err error
+		if containsFold(record.Values, value) && !kept[value] {
+			kept[value] = true
+			if overrideUnchanged(row, record, ttl) {
+				p.log.V(1).Info("record unchanged", "uuid", row.UUID)
+				continue
+			}
+			err = p.setOverride(ctx, row.UUID, record, row.Server)
+		} else {
+			err = p.delOverride(ctx, row.UUID)
+		}
+		if err != nil {
+			return written, err
+		}
+		written++
 	}
-	if result.Result != "saved" {
-		return fmt.Errorf("opnsense: setHostOverride unexpected result: %s", result.Result)
+	for _, value := range record.Values {
+		if kept[strings.ToLower(value)] {
+			continue
+		}
+		kept[strings.ToLower(value)] = true
+		if err := p.addOverride(ctx, record, value); err != nil {
+			return written, err
+		}
+		written++
 	}
-
-	p.log.V(1).Info("record updated", "uuid", uuid)
-	return p.reconfigure(ctx)
+	return written, nil
 }
 
-// Delete removes a DNS host override.
-func (p *Provider) Delete(ctx context.Context, hostname, recordType string) error {
-	p.log.V(1).Info("deleting record", "hostname", hostname, "type", recordType)
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
 
-	uuid, err := p.findOverride(ctx, hostname, recordType)
-	if err != nil {
-		return err
+// Exists checks whether a DNS host override, or a host alias for CNAME
+// records, exists for the given hostname and record type.
+func (p *Provider) Exists(ctx context.Context, hostname, recordType string) (bool, error) {
+	p.log.V(1).Info("checking if record exists", "hostname", hostname, "type", recordType)
+	if isAlias(recordType) {
+		_, ok, err := p.findAlias(ctx, hostname)
+		return ok, err
 	}
-	if uuid == "" {
-		p.log.V(1).Info("opnsense: no existing override found for deletion", hostname, recordType)
-		return nil
+	idx, err := p.overrides(ctx)
+	if err != nil {
+		return false, err
 	}
+	return len(idx.match(hostname, recordType)) > 0, nil
+}
 
-	resp, err := p.doRequest(ctx, http.MethodPost, fmt.Sprintf("unbound/settings/delHostOverride/%s", uuid), struct{}{})
+// List returns all host overrides and host aliases matching the filter. Host
+// overrides of the same hostname and type are returned as a single record with
+// several values; aliases are listed as CNAME records pointing to their host
+// override.
+func (p *Provider) List(ctx context.Context, filter dns.ListFilter) ([]dns.Record, error) {
+	idx, err := p.overrides(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("opnsense: delHostOverride returned status %d: %s", resp.StatusCode, string(respBody))
+	aliases, err := p.aliases(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var result struct {
-		Result string `json:"result"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("opnsense: decode delHostOverride response: %w", err)
+	var records []dns.Record
+	for _, rec := range groupRecords(idx.rows) {
+		if filter.Matches(rec) {
+			records = append(records, rec)
+		}
 	}
-	if result.Result == "deleted" {
-		p.log.V(1).Info("record deleted", "uuid", uuid)
-	} else if result.Result == "not found" {
-		p.log.V(1).Info("record already deleted", "uuid", uuid)
-	} else {
-		return fmt.Errorf("opnsense: delHostOverride unexpected result: %s", result.Result)
+	for _, row := range aliases {
+		rec := row.toRecord()
+		if filter.Matches(rec) {
+			records = append(records, rec)
+		}
 	}
+	p.log.V(1).Info("listed records", "total", len(idx.rows)+len(aliases), "matched", len(records))
+	return records, nil
+}
+
+// Create adds the host overrides for a record, one per value, or a host alias
+// for CNAME records.
+func (p *Provider) Create(ctx context.Context, record dns.Record) error {
+	p.log.V(1).Info("creating record", "hostname", record.Hostname, "type", record.Type, "values", record.Values)
+	return p.ApplyChanges(ctx, dns.ChangeSet{Creates: []dns.Record{record}})
+}
+
+// Update replaces the host overrides for a record's hostname and type with one
+// per value, or retargets the host alias for CNAME records.
+func (p *Provider) Update(ctx context.Context, record dns.Record) error {
+	p.log.V(1).Info("updating record", "hostname", record.Hostname, "type", record.Type, "values", record.Values)
+	return p.ApplyChanges(ctx, dns.ChangeSet{Updates: []dns.Record{record}})
+}
 
-	p.log.V(1).Info("record deleted", "uuid", uuid)
-	return p.reconfigure(ctx)
+// Delete removes all host overrides for a hostname and record type, or the
+// host alias for CNAME records.
+func (p *Provider) Delete(ctx context.Context, hostname, recordType string) error {
+	p.log.V(1).Info("deleting record", "hostname", hostname, "type", recordType)
+	return p.ApplyChanges(ctx, dns.ChangeSet{Deletes: []dns.Record{{Hostname: hostname, Type: recordType}}})
 }
 
 // Upsert creates or updates a DNS record depending on whether it already exists.
@@ -361,3 +580,211 @@ func (p *Provider) Upsert(ctx context.Context, record dns.Record) error {
 	}
 	return p.Create(ctx, record)
 }
+
+// deleteRecord removes the host overrides, or the host alias for CNAME
+// records, held for a record's hostname and type. It returns the number of
+// rows written.
+func (p *Provider) deleteRecord(ctx context.Context, idx *overrideIndex, aliases []aliasRow, rec dns.Record) (int, error) {
+	if isAlias(rec.Type) {
+		row, ok := matchAlias(aliases, rec.Hostname)
+		if !ok {
+			p.log.V(1).Info("no existing alias found for deletion", "hostname", rec.Hostname)
+			return 0, nil
+		}
+		if err := dns.CheckOwner(rec, row.toRecord()); err != nil {
+			return 0, fmt.Errorf("opnsense: %w", err)
+		}
+		if err := p.delAlias(ctx, row.UUID); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	matched := idx.match(rec.Hostname, rec.Type)
+	if len(matched) == 0 {
+		p.log.V(1).Info("no existing override found for deletion", "hostname", rec.Hostname, "type", rec.Type)
+		return 0, nil
+	}
+	if err := checkOwner(rec, matched); err != nil {
+		return 0, err
+	}
+	written := 0
+	for _, row := range matched {
+		if err := p.delOverride(ctx, row.UUID); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// updateRecord rewrites the host overrides, or the host alias for CNAME
+// records, held for a record's hostname and type. It returns the number of
+// rows written.
+func (p *Provider) updateRecord(ctx context.Context, idx *overrideIndex, aliases []aliasRow, rec dns.Record) (int, error) {
+	if isAlias(rec.Type) {
+		row, ok := matchAlias(aliases, rec.Hostname)
+		if !ok {
+			return 0, fmt.Errorf("opnsense: no existing alias found for %s", rec.Hostname)
+		}
+		if err := dns.CheckOwner(rec, row.toRecord()); err != nil {
+			return 0, fmt.Errorf("opnsense: %w", err)
+		}
+		if aliasUnchanged(row, rec) {
+			p.log.V(1).Info("alias unchanged", "hostname", rec.Hostname, "uuid", row.UUID)
+			return 0, nil
+		}
+		hostUUID, err := targetUUID(idx, rec)
+		if err != nil {
+			return 0, err
+		}
+		if err := p.setAlias(ctx, row.UUID, hostUUID, rec); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	matched := idx.match(rec.Hostname, rec.Type)
+	if len(matched) == 0 {
+		return 0, fmt.Errorf("opnsense: no existing override found for %s/%s", rec.Hostname, rec.Type)
+	}
+	if err := checkOwner(rec, matched); err != nil {
+		return 0, err
+	}
+	n, err := p.syncOverrides(ctx, matched, rec)
+	if n == 0 && err == nil {
+		p.log.V(1).Info("record unchanged, skipping update", "hostname", rec.Hostname, "type", rec.Type)
+	}
+	return n, err
+}
+
+// hasAlias reports whether any of the records is stored as a host alias.
+func hasAlias(records ...[]dns.Record) bool {
+	for _, recs := range records {
+		for _, rec := range recs {
+			if isAlias(rec.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApplyChanges applies all creates, updates and deletes using a single read
+// of the host override table (and of the host alias table when CNAME records
+// are involved), served from the cache while it is fresh, and a single
+// reconfigure at the end. Each value of a record is
+// a separate host override, so updates add and remove individual rows until
+// the hostname holds exactly the record's values. Rows that already match are
+// not rewritten, and Unbound is not reconfigured when nothing was written. Deletes run first, so a
+// hostname can switch between an override and an alias in one batch. Updates
+// and deletes of records owned by someone else are skipped and reported with
+// dns.ErrNotOwned once the rest of the batch has been applied.
+func (p *Provider) ApplyChanges(ctx context.Context, changes dns.ChangeSet) error {
+	if changes.IsEmpty() {
+		return nil
+	}
+	p.log.V(1).Info("applying changes",
+		"creates", len(changes.Creates), "updates", len(changes.Updates), "deletes", len(changes.Deletes))
+
+	if len(changes.Creates) > 0 || len(changes.Updates) > 0 {
+		if _, err := p.detectTTL(ctx); err != nil {
+			return err
+		}
+	}
+
+	idx := newOverrideIndex(nil)
+	if len(changes.Updates) > 0 || len(changes.Deletes) > 0 || hasAlias(changes.Creates) {
+		var err error
+		idx, err = p.overrides(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	var aliases []aliasRow
+	if hasAlias(changes.Updates, changes.Deletes) {
+		var err error
+		aliases, err = p.aliases(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	var denied []error
+	applied := 0
+	for _, rec := range changes.Deletes {
+		n, err := p.deleteRecord(ctx, idx, aliases, rec)
+		applied += n
+		if errors.Is(err, dns.ErrNotOwned) {
+			denied = append(denied, err)
+		} else if err != nil {
+			return p.finishChanges(ctx, applied, err)
+		}
+	}
+	for _, rec := range changes.Updates {
+		n, err := p.updateRecord(ctx, idx, aliases, rec)
+		applied += n
+		if errors.Is(err, dns.ErrNotOwned) {
+			denied = append(denied, err)
+		} else if err != nil {
+			return p.finishChanges(ctx, applied, err)
+		}
+	}
+	// Overrides are created before aliases, which may be attached to them.
+	created := false
+	for _, rec := range changes.Creates {
+		if isAlias(rec.Type) {
+			continue
+		}
+		n, err := p.syncOverrides(ctx, nil, rec)
+		applied += n
+		if err != nil {
+			return p.finishChanges(ctx, applied, err)
+		}
+		created = true
+	}
+	for _, rec := range changes.Creates {
+		if !isAlias(rec.Type) {
+			continue
+		}
+		if _, ok := matchTarget(idx, aliasTarget(rec)); !ok && created {
+			// The target may be one of the overrides created above.
+			var err error
+			if idx, err = p.overrides(ctx); err != nil {
+				return p.finishChanges(ctx, applied, err)
+			}
+			created = false
+		}
+		hostUUID, err := targetUUID(idx, rec)
+		if err == nil {
+			err = p.addAlias(ctx, hostUUID, rec)
+		}
+		if err != nil {
+			return p.finishChanges(ctx, applied, err)
+		}
+		applied++
+	}
+	if err := p.finishChanges(ctx, applied, nil); err != nil {
+		return err
+	}
+	return errors.Join(denied...)
+}
+
+// finishChanges reconfigures Unbound if any change was written, so that a
+// partially applied batch still takes effect, and returns the original error.
+func (p *Provider) finishChanges(ctx context.Context, applied int, err error) error {
+	if applied == 0 {
+		return err
+	}
+	rerr := p.reconfigure(ctx)
+	p.mu.Lock()
+	reconfigured := p.reconfigured
+	p.mu.Unlock()
+	if reconfigured != nil {
+		reconfigured(rerr)
+	}
+	if rerr != nil && err == nil {
+		return rerr
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package opnsense
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 )
@@ -56,6 +57,20 @@ func TestNew_InvalidTTL(t *testing.T) {
 	}
 }
 
+func TestNew_NonPositiveTTL(t *testing.T) {
+	settings := map[string]string{
+		"base_url":    "https://opnsense.local/api",
+		"api_key":     "key123",
+		"api_secret":  "secret456",
+		"default_ttl": "0",
+	}
+
+	_, err := New(logr.Discard(), settings)
+	if err == nil {
+		t.Fatal("expected error for a default_ttl of 0, got nil")
+	}
+}
+
 func TestNew_MissingBaseURL(t *testing.T) {
 	settings := map[string]string{
 		"api_key":    "key123",
@@ -108,3 +123,66 @@ func TestNew_SkipTLSVerify(t *testing.T) {
 		t.Fatal("expected non-nil HTTP client")
 	}
 }
+
+func TestNew_CacheTTL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", defaultCacheTTL, false},
+		{"30s", 30 * time.Second, false},
+		{"0", 0, false},
+		{"-1s", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p, err := New(logr.Discard(), map[string]string{
+				"base_url":   "https://opnsense.local/api",
+				"api_key":    "key123",
+				"api_secret": "secret456",
+				"cache_ttl":  tt.value,
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for cache_ttl %q, got nil", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.overrideCache.ttl != tt.want || p.aliasCache.ttl != tt.want {
+				t.Errorf("expected cache TTL %s, got %s and %s", tt.want, p.overrideCache.ttl, p.aliasCache.ttl)
+			}
+		})
+	}
+}
+
+func TestDescriptionOwnerRoundTrip(t *testing.T) {
+	tests := []struct {
+		description string
+		owner       string
+		encoded     string
+	}{
+		{"managed by yk-dns-manager", "prod/default/web", "managed by yk-dns-manager [owner=prod/default/web]"},
+		{"", "prod/default/web", " [owner=prod/default/web]"},
+		{"my NAS", "", "my NAS"},
+		{"notes [owner=someone", "", "notes [owner=someone"}, // unterminated tag is not an owner
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoded, func(t *testing.T) {
+			if got := encodeDescription(tt.description, tt.owner); got != tt.encoded {
+				t.Errorf("encodeDescription(%q, %q) = %q, want %q", tt.description, tt.owner, got, tt.encoded)
+			}
+			description, owner := parseDescription(tt.encoded)
+			if description != tt.description || owner != tt.owner {
+				t.Errorf("parseDescription(%q) = (%q, %q), want (%q, %q)",
+					tt.encoded, description, owner, tt.description, tt.owner)
+			}
+		})
+	}
+}
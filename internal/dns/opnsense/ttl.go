@@ -0,0 +1,91 @@
+package opnsense
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Host overrides have a ttl field on recent OPNsense versions only. Older
+// versions answer every override with the Unbound default TTL, so records are
+// written without one.
+
+// detectTTL reports whether host overrides on this firewall have a ttl field,
+// by asking for the defaults of a new override. The answer is cached once it
+// has been obtained. The request is made without holding p.mu, so concurrent
+// first calls may each ask; the first answer is kept.
+func (p *Provider) detectTTL(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	checked, supported := p.ttlChecked, p.ttlSupported
+	p.mu.Unlock()
+	if checked {
+		return supported, nil
+	}
+
+	supported, err := p.fetchTTLSupport(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.ttlChecked {
+		p.ttlSupported, p.ttlChecked = supported, true
+		if !supported {
+			p.log.Info("this OPNsense version has no TTL for host overrides, records use the Unbound default TTL")
+		}
+	}
+	return p.ttlSupported, nil
+}
+
+// fetchTTLSupport asks the firewall for the defaults of a new host override
+// and reports whether they include a ttl field.
+func (p *Provider) fetchTTLSupport(ctx context.Context) (bool, error) {
+	resp, err := p.doRequest(ctx, http.MethodGet, "unbound/settings/getHostOverride", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, statusError("getHostOverride", resp.StatusCode, "")
+	}
+	var defaults struct {
+		Host map[string]json.RawMessage `json:"host"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&defaults); err != nil {
+		return false, fmt.Errorf("opnsense: decode getHostOverride response: %w", err)
+	}
+	_, ok := defaults.Host["ttl"]
+	return ok, nil
+}
+
+// recordTTL returns the TTL to write for a record: its own, or default_ttl
+// when it has none. It returns 0 when the firewall doesn't support TTLs.
+func (p *Provider) recordTTL(record dns.Record) int {
+	p.mu.Lock()
+	supported := p.ttlSupported
+	p.mu.Unlock()
+	switch {
+	case !supported:
+		return 0
+	case record.TTL > 0:
+		return record.TTL
+	default:
+		return p.defaultTTL
+	}
+}
+
+// parseTTL parses the ttl field of a host override row, which is empty on
+// versions without TTL support and for overrides using the Unbound default.
+func parseTTL(value string) int {
+	ttl, err := strconv.Atoi(value)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,49 @@
+package opnsense
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+func TestDetectTTL_DoesNotBlockDuringRequest(t *testing.T) {
+	var p *Provider
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Setting zones needs p.mu, which must not be held while detectTTL waits.
+		done := make(chan struct{})
+		go func() {
+			p.SetZones(dns.Zones{})
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("SetZones blocked while the TTL support request was in flight")
+		}
+		w.Write([]byte(`{"host":{"hostname":"","ttl":""}}`))
+	}))
+	defer srv.Close()
+
+	p, err := New(logr.Discard(), map[string]string{
+		"base_url":   srv.URL,
+		"api_key":    "key123",
+		"api_secret": "secret456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	supported, err := p.detectTTL(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !supported {
+		t.Error("expected TTL support to be detected")
+	}
+}
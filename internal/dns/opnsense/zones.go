@@ -0,0 +1,65 @@
+package opnsense
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// Host overrides and aliases are stored as a hostname inside a domain, where
+// the domain should be the Unbound zone the record belongs to. With zones set,
+// "a.b.example.com" in zone "example.com" is written as hostname "a.b" and the
+// zone apex with an empty hostname. Without them, the hostname is the first
+// label. Existing rows are matched by their full name, so rows written with a
+// different split are still found and updated in place.
+//
+// Unbound only treats a host override named "*" as a wildcard: OPNsense turns
+// it into a redirect zone for its domain, answering every name in it, the
+// domain itself included. A wildcard is therefore always written as "*" in
+// the domain it covers, whatever the zones.
+
+// SetZones sets the zones hostnames are split on, implementing dns.ZoneSetter.
+func (p *Provider) SetZones(zones dns.Zones) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.zones = zones
+	p.log.V(1).Info("set DNS zones", "zones", zones.Names())
+}
+
+// split splits a hostname into the hostname and domain fields of a row.
+func (p *Provider) split(fqdn string) (host, domain string) {
+	if dns.IsWildcard(fqdn) {
+		return "*", dns.CanonicalHostname(strings.TrimPrefix(fqdn, "*."))
+	}
+	p.mu.Lock()
+	zones := p.zones
+	p.mu.Unlock()
+	return zones.Split(fqdn)
+}
+
+// SupportsWildcard implements dns.WildcardSupporter. Wildcard A and AAAA
+// records are host overrides named "*". Host aliases have no wildcard form.
+func (p *Provider) SupportsWildcard(recordType string) bool {
+	return !isAlias(recordType)
+}
+
+// hostBody returns the JSON body for add/set host override calls holding one
+// of the record's values.
+func (p *Provider) hostBody(record dns.Record, value string) map[string]interface{} {
+	host, domain := p.split(record.Hostname)
+	return buildHostBody(host, domain, record, value, p.recordTTL(record))
+}
+
+// aliasBody returns the JSON body for add/set host alias calls. A CNAME can't
+// live at the zone apex, next to the zone's SOA and NS records.
+func (p *Provider) aliasBody(record dns.Record, hostUUID string) (map[string]interface{}, error) {
+	if dns.IsWildcard(record.Hostname) {
+		return nil, dns.Classify(fmt.Errorf("opnsense: wildcard CNAME %s is not supported", record.Hostname), dns.ErrNonRetryable)
+	}
+	host, domain := p.split(record.Hostname)
+	if host == "" {
+		return nil, dns.Classify(fmt.Errorf("opnsense: CNAME %s is not allowed at the zone apex", record.Hostname), dns.ErrNonRetryable)
+	}
+	return buildAliasBody(host, domain, record, hostUUID), nil
+}
@@ -0,0 +1,115 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MetaOwner is the Record.Meta key holding the owner ID of a record.
+const MetaOwner = "owner"
+
+// MetaPreviousOwner is the Record.Meta key of a change handing a record over
+// from the owner it names to the change's owner. It is not stored.
+const MetaPreviousOwner = "previous-owner"
+
+// ManagedDescription is the description yk-dns-manager writes on its records.
+// Records carrying it without an owner ID were created before ownership
+// tracking existed and may be adopted by any owner.
+const ManagedDescription = "managed by yk-dns-manager"
+
+// ErrNotOwned is returned when a change targets a record created by someone else.
+var ErrNotOwned = errors.New("record not owned")
+
+// OwnershipError reports a change refused because the existing record belongs
+// to someone else. It matches ErrNotOwned with errors.Is.
+type OwnershipError struct {
+	Hostname string
+	Type     string
+	Owner    string // owner of the existing record, empty if not created by yk-dns-manager
+	Wanted   string // owner carried by the refused change
+}
+
+func (e *OwnershipError) Error() string {
+	if e.Owner == "" {
+		return fmt.Sprintf("%s: %s/%s was not created by yk-dns-manager", ErrNotOwned, e.Hostname, e.Type)
+	}
+	return fmt.Sprintf("%s: %s/%s is owned by %q, not %q", ErrNotOwned, e.Hostname, e.Type, e.Owner, e.Wanted)
+}
+
+func (e *OwnershipError) Unwrap() error {
+	return ErrNotOwned
+}
+
+// OwnershipErrors returns every OwnershipError in err, including those
+// combined with errors.Join.
+func OwnershipErrors(err error) []*OwnershipError {
+	var found []*OwnershipError
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *OwnershipError:
+			found = append(found, e)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return found
+}
+
+// OwnerID builds the owner ID for a record claimed by a Kubernetes object.
+// e.g. OwnerID("prod", "HTTPRoute", "default", "web") → "prod/HTTPRoute/default/web"
+func OwnerID(cluster, kind, namespace, name string) string {
+	return cluster + "/" + kind + "/" + namespace + "/" + name
+}
+
+// LegacyOwnerID returns the owner ID written for the same object before owner
+// IDs included the kind, or "" if owner is not a kinded owner ID.
+// e.g. LegacyOwnerID("prod/HTTPRoute/default/web") → "prod/default/web"
+func LegacyOwnerID(owner string) string {
+	parts := strings.Split(owner, "/")
+	if len(parts) != 4 {
+		return ""
+	}
+	return parts[0] + "/" + parts[2] + "/" + parts[3]
+}
+
+// Owner returns the owner ID stored in the record's metadata, if any.
+func (r Record) Owner() string {
+	if r.Meta == nil {
+		return ""
+	}
+	return r.Meta[MetaOwner]
+}
+
+// CheckOwner verifies that a change carrying desired's owner may modify the
+// existing record. Changes without an owner are not checked, and changes
+// naming the existing owner in MetaPreviousOwner take the record over. Records
+// tagged with the legacy owner ID of desired's owner are adopted like records
+// from before ownership tracking. It returns an *OwnershipError when existing
+// belongs to a different owner or was not created by yk-dns-manager at all.
+func CheckOwner(desired, existing Record) error {
+	owner := desired.Owner()
+	if owner == "" {
+		return nil
+	}
+	switch existing.Owner() {
+	case owner:
+		return nil
+	case "":
+		if existing.Meta != nil && strings.HasPrefix(existing.Meta["description"], ManagedDescription) {
+			return nil
+		}
+		return &OwnershipError{Hostname: existing.Hostname, Type: existing.Type, Wanted: owner}
+	case desired.Meta[MetaPreviousOwner], LegacyOwnerID(owner):
+		return nil
+	default:
+		return &OwnershipError{Hostname: existing.Hostname, Type: existing.Type, Owner: existing.Owner(), Wanted: owner}
+	}
+}
@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCheckOwner(t *testing.T) {
+	desired := Record{Hostname: "app.example.com", Type: "A", Meta: map[string]string{MetaOwner: "prod/HTTPRoute/default/web"}}
+
+	tests := []struct {
+		name     string
+		desired  Record
+		existing Record
+		wantErr  bool
+	}{
+		{
+			name:     "same owner",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/HTTPRoute/default/web"}},
+		},
+		{
+			name:     "different owner",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/HTTPRoute/default/other"}},
+			wantErr:  true,
+		},
+		{
+			name:     "hand-made record",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{"description": "my NAS"}},
+			wantErr:  true,
+		},
+		{
+			name:     "hand-made record without meta",
+			desired:  desired,
+			existing: Record{},
+			wantErr:  true,
+		},
+		{
+			name:     "legacy managed record is adopted",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{"description": ManagedDescription}},
+		},
+		{
+			name:     "same name of another kind",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/Ingress/default/web"}},
+			wantErr:  true,
+		},
+		{
+			name:     "legacy owner ID is adopted",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/default/web"}},
+		},
+		{
+			name:     "legacy owner ID of another object",
+			desired:  desired,
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/default/other"}},
+			wantErr:  true,
+		},
+		{
+			name: "handover from the previous owner",
+			desired: Record{Hostname: "app.example.com", Type: "A", Meta: map[string]string{
+				MetaOwner: "prod/HTTPRoute/default/web", MetaPreviousOwner: "prod/HTTPRoute/default/canary",
+			}},
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/HTTPRoute/default/canary"}},
+		},
+		{
+			name: "handover from someone else",
+			desired: Record{Hostname: "app.example.com", Type: "A", Meta: map[string]string{
+				MetaOwner: "prod/HTTPRoute/default/web", MetaPreviousOwner: "prod/HTTPRoute/default/canary",
+			}},
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/HTTPRoute/default/other"}},
+			wantErr:  true,
+		},
+		{
+			name: "handover of a hand-made record",
+			desired: Record{Hostname: "app.example.com", Type: "A", Meta: map[string]string{
+				MetaOwner: "prod/HTTPRoute/default/web", MetaPreviousOwner: "prod/HTTPRoute/default/canary",
+			}},
+			existing: Record{},
+			wantErr:  true,
+		},
+		{
+			name:     "no owner on change skips the check",
+			desired:  Record{Hostname: "app.example.com", Type: "A"},
+			existing: Record{Meta: map[string]string{MetaOwner: "prod/HTTPRoute/default/other"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckOwner(tt.desired, tt.existing)
+			if tt.wantErr && !errors.Is(err, ErrNotOwned) {
+				t.Errorf("expected ErrNotOwned, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOwnershipErrors(t *testing.T) {
+	first := &OwnershipError{Hostname: "a.example.com", Type: "A", Owner: "prod/default/x", Wanted: "prod/default/y"}
+	second := &OwnershipError{Hostname: "b.example.com", Type: "A", Wanted: "prod/default/y"}
+	err := errors.Join(fmt.Errorf("opnsense: %w", first), fmt.Errorf("opnsense: %w", second))
+
+	if !errors.Is(err, ErrNotOwned) {
+		t.Fatalf("expected joined error to match ErrNotOwned")
+	}
+	found := OwnershipErrors(err)
+	if len(found) != 2 || found[0] != first || found[1] != second {
+		t.Errorf("expected both ownership errors, got %v", found)
+	}
+	if OwnershipErrors(errors.New("boom")) != nil {
+		t.Errorf("expected no ownership errors in an unrelated error")
+	}
+}
@@ -3,6 +3,7 @@ package dns
 import (
 	"context"
 	"errors"
+	"strings"
 )
 
 var (
@@ -18,17 +19,86 @@ var (
 type Record struct {
 	Hostname string            // FQDN, e.g. "app.example.com"
 	Type     string            // "A", "AAAA", "CNAME"
-	Value    string            // IP address or target
+	Values   []string          // IP addresses or target, one record per value, e.g. round-robin A records
 	TTL      int               // 0 = provider default
 	Meta     map[string]string // provider-specific fields (e.g. "description")
 }
 
+// EqualValues reports whether two value sets hold the same values, ignoring
+// order, duplicates and case.
+func EqualValues(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		set[strings.ToLower(v)] = true
+	}
+	other := make(map[string]bool, len(b))
+	for _, v := range b {
+		v = strings.ToLower(v)
+		if !set[v] {
+			return false
+		}
+		other[v] = true
+	}
+	return len(set) == len(other)
+}
+
+// EqualTTL reports whether an actual TTL satisfies a desired one. A desired
+// TTL of 0 leaves the TTL to the provider, and an actual TTL of 0 means the
+// provider doesn't report TTLs, so either matches any TTL.
+func EqualTTL(desired, actual int) bool {
+	return desired == 0 || actual == 0 || desired == actual
+}
+
+// Unchanged reports whether an existing record already holds everything a
+// desired one would write: the same values, a matching TTL, description and
+// owner. Updating such a record is a no-op.
+func Unchanged(desired, existing Record) bool {
+	return EqualValues(desired.Values, existing.Values) &&
+		EqualTTL(desired.TTL, existing.TTL) &&
+		desired.Meta["description"] == existing.Meta["description"] &&
+		desired.Owner() == existing.Owner()
+}
+
+// ListFilter narrows the records returned by Provider.List.
+// Empty fields match all records.
+type ListFilter struct {
+	Hostname string // exact FQDN match
+	Type     string // record type, e.g. "A"
+	Domain   string // matches the domain itself and any hostname below it
+}
+
+// Matches reports whether the record satisfies the filter.
+func (f ListFilter) Matches(r Record) bool {
+	hostname := strings.TrimSuffix(r.Hostname, ".")
+	if f.Hostname != "" && !strings.EqualFold(hostname, strings.TrimSuffix(f.Hostname, ".")) {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(r.Type, f.Type) {
+		return false
+	}
+	if f.Domain != "" {
+		domain := strings.ToLower(strings.TrimSuffix(f.Domain, "."))
+		hostname = strings.ToLower(hostname)
+		if hostname != domain && !strings.HasSuffix(hostname, "."+domain) {
+			return false
+		}
+	}
+	return true
+}
+
 // Provider is the interface that DNS providers must implement.
 type Provider interface {
 	Exists(ctx context.Context, hostname, recordType string) (bool, error)
+	// List returns the records currently held by the provider that match the filter.
+	List(ctx context.Context, filter ListFilter) ([]Record, error)
 	Create(ctx context.Context, record Record) error
 	Update(ctx context.Context, record Record) error
 	Delete(ctx context.Context, hostname, recordType string) error
 	Upsert(ctx context.Context, record Record) error
+	// ApplyChanges submits a batch of creates, updates and deletes together.
+	// Updates and deletes of records owned by someone else are skipped and
+	// reported with ErrNotOwned after the rest of the batch is applied.
+	// Providers that cannot batch may delegate to ApplySequentially.
+	ApplyChanges(ctx context.Context, changes ChangeSet) error
 	HealthCheck(ctx context.Context) error
 }
@@ -0,0 +1,106 @@
+package dns
+
+import "testing"
+
+func TestListFilterMatches(t *testing.T) {
+	rec := Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}}
+
+	tests := []struct {
+		name   string
+		filter ListFilter
+		want   bool
+	}{
+		{"empty filter", ListFilter{}, true},
+		{"exact hostname", ListFilter{Hostname: "app.example.com"}, true},
+		{"hostname with trailing dot", ListFilter{Hostname: "APP.example.com."}, true},
+		{"other hostname", ListFilter{Hostname: "api.example.com"}, false},
+		{"matching type", ListFilter{Type: "a"}, true},
+		{"other type", ListFilter{Type: "AAAA"}, false},
+		{"parent domain", ListFilter{Domain: "example.com"}, true},
+		{"same domain", ListFilter{Domain: "app.example.com"}, true},
+		{"suffix but not a label boundary", ListFilter{Domain: "le.com"}, false},
+		{"other domain", ListFilter{Domain: "example.org"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(rec); got != tt.want {
+				t.Errorf("Matches(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want bool
+	}{
+		{"both empty", nil, []string{}, true},
+		{"same values", []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.1", "10.0.0.2"}, true},
+		{"different order", []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.2", "10.0.0.1"}, true},
+		{"different case", []string{"Gateway.example.com"}, []string{"gateway.example.com"}, true},
+		{"duplicates", []string{"10.0.0.1", "10.0.0.1"}, []string{"10.0.0.1"}, true},
+		{"missing value", []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.1"}, false},
+		{"other value", []string{"10.0.0.1"}, []string{"10.0.0.2"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EqualValues(tt.a, tt.b); got != tt.want {
+				t.Errorf("EqualValues(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualTTL(t *testing.T) {
+	tests := []struct {
+		name            string
+		desired, actual int
+		want            bool
+	}{
+		{"same TTL", 300, 300, true},
+		{"provider default", 0, 300, true},
+		{"not reported", 300, 0, true},
+		{"different TTL", 30, 300, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EqualTTL(tt.desired, tt.actual); got != tt.want {
+				t.Errorf("EqualTTL(%d, %d) = %v, want %v", tt.desired, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnchanged(t *testing.T) {
+	owned := map[string]string{"description": ManagedDescription, MetaOwner: "prod/default/web"}
+	desired := Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1", "10.0.0.2"}, TTL: 300, Meta: owned}
+
+	tests := []struct {
+		name     string
+		existing Record
+		want     bool
+	}{
+		{"identical", desired, true},
+		{"values reordered", Record{Values: []string{"10.0.0.2", "10.0.0.1"}, TTL: 300, Meta: owned}, true},
+		{"TTL not reported", Record{Values: desired.Values, Meta: owned}, true},
+		{"value differs", Record{Values: []string{"10.0.0.1"}, TTL: 300, Meta: owned}, false},
+		{"TTL differs", Record{Values: desired.Values, TTL: 60, Meta: owned}, false},
+		{"description differs", Record{Values: desired.Values, TTL: 300,
+			Meta: map[string]string{"description": "edited", MetaOwner: "prod/default/web"}}, false},
+		{"legacy record without owner", Record{Values: desired.Values, TTL: 300,
+			Meta: map[string]string{"description": ManagedDescription}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unchanged(desired, tt.existing); got != tt.want {
+				t.Errorf("Unchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
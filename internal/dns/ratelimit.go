@@ -0,0 +1,84 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit controls how often calls writing to the DNS server are made.
+type RateLimit struct {
+	WritesPerSecond float64 // sustained rate of write calls, 0 disables the limit
+	Burst           int     // write calls allowed at once, 0 means WritesPerSecond rounded up
+}
+
+// WithRateLimit returns a middleware passing write calls (Create, Update,
+// Delete, Upsert and ApplyChanges) through a token bucket: each call takes a
+// token and waits for one while the bucket is empty. A change set takes a
+// single token however many records it holds. Reads and HealthCheck are not
+// limited.
+func WithRateLimit(limit RateLimit) Middleware {
+	if limit.WritesPerSecond <= 0 {
+		return noMiddleware
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = max(int(math.Ceil(limit.WritesPerSecond)), 1)
+	}
+	return func(p Provider) Provider {
+		return &rateLimited{wrapped: wrapped{p}, limiter: rate.NewLimiter(rate.Limit(limit.WritesPerSecond), burst)}
+	}
+}
+
+type rateLimited struct {
+	wrapped
+	limiter *rate.Limiter
+}
+
+// wait takes a token, waiting until one is available or ctx is done.
+func (r *rateLimited) wait(ctx context.Context) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("dns: waiting for the write rate limit: %w", err)
+	}
+	return nil
+}
+
+func (r *rateLimited) Create(ctx context.Context, record Record) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+	return r.Provider.Create(ctx, record)
+}
+
+func (r *rateLimited) Update(ctx context.Context, record Record) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+	return r.Provider.Update(ctx, record)
+}
+
+func (r *rateLimited) Delete(ctx context.Context, hostname, recordType string) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+	return r.Provider.Delete(ctx, hostname, recordType)
+}
+
+func (r *rateLimited) Upsert(ctx context.Context, record Record) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+	return r.Provider.Upsert(ctx, record)
+}
+
+func (r *rateLimited) ApplyChanges(ctx context.Context, changes ChangeSet) error {
+	if changes.IsEmpty() {
+		return r.Provider.ApplyChanges(ctx, changes)
+	}
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+	return r.Provider.ApplyChanges(ctx, changes)
+}
@@ -0,0 +1,170 @@
+package dns
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// RetryPolicy controls how calls failing with a retryable error are repeated.
+type RetryPolicy struct {
+	Attempts       int           // calls per operation including the first, 1 or less disables retries
+	InitialBackoff time.Duration // delay before the first retry, doubled for each further one
+	MaxBackoff     time.Duration // upper bound of the delay, 0 means no bound
+}
+
+// backoff returns the delay before retry n, counting from 0: the doubled
+// initial backoff, capped at the maximum, of which a random half is waited.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < n && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// WithRetry returns a middleware repeating calls that fail with a retryable
+// error, see IsRetryable, with jittered exponential backoff. HealthCheck is
+// never repeated, so it reports the provider's current state.
+//
+// A change set is applied again as a whole. Its creates of records that exist
+// by then, e.g. because the failed attempt wrote them before it broke off,
+// are retried as updates instead, so they are not written twice.
+func WithRetry(policy RetryPolicy, log logr.Logger) Middleware {
+	if policy.Attempts <= 1 {
+		return noMiddleware
+	}
+	return func(p Provider) Provider {
+		return &retrying{wrapped: wrapped{p}, policy: policy, log: log, sleep: sleepContext}
+	}
+}
+
+type retrying struct {
+	wrapped
+	policy RetryPolicy
+	log    logr.Logger
+	sleep  func(context.Context, time.Duration) error
+}
+
+// do calls fn until it succeeds, fails with an error that is not retryable,
+// runs out of attempts or ctx is done, and returns its last error.
+func (r *retrying) do(ctx context.Context, op string, fn func(attempt int) error) error {
+	var err error
+	for attempt := range r.policy.Attempts {
+		if attempt > 0 {
+			delay := r.policy.backoff(attempt - 1)
+			r.log.V(1).Info("retrying DNS provider call", "op", op, "attempt", attempt+1, "delay", delay, "error", err.Error())
+			if r.sleep(ctx, delay) != nil {
+				return err
+			}
+		}
+		if err = fn(attempt); !IsRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// sleepContext waits for d or until ctx is done, returning ctx's error then.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *retrying) Exists(ctx context.Context, hostname, recordType string) (bool, error) {
+	var exists bool
+	err := r.do(ctx, opExists, func(int) error {
+		var err error
+		exists, err = r.Provider.Exists(ctx, hostname, recordType)
+		return err
+	})
+	return exists, err
+}
+
+func (r *retrying) List(ctx context.Context, filter ListFilter) ([]Record, error) {
+	var records []Record
+	err := r.do(ctx, opList, func(int) error {
+		var err error
+		records, err = r.Provider.List(ctx, filter)
+		return err
+	})
+	return records, err
+}
+
+func (r *retrying) Create(ctx context.Context, record Record) error {
+	return r.do(ctx, opCreate, func(attempt int) error {
+		if attempt > 0 {
+			exists, err := r.Provider.Exists(ctx, record.Hostname, record.Type)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return r.Provider.Update(ctx, record)
+			}
+		}
+		return r.Provider.Create(ctx, record)
+	})
+}
+
+func (r *retrying) Update(ctx context.Context, record Record) error {
+	return r.do(ctx, opUpdate, func(int) error {
+		return r.Provider.Update(ctx, record)
+	})
+}
+
+func (r *retrying) Delete(ctx context.Context, hostname, recordType string) error {
+	return r.do(ctx, opDelete, func(int) error {
+		return r.Provider.Delete(ctx, hostname, recordType)
+	})
+}
+
+func (r *retrying) Upsert(ctx context.Context, record Record) error {
+	return r.do(ctx, opUpsert, func(int) error {
+		return r.Provider.Upsert(ctx, record)
+	})
+}
+
+func (r *retrying) ApplyChanges(ctx context.Context, changes ChangeSet) error {
+	return r.do(ctx, opApplyChanges, func(attempt int) error {
+		if attempt > 0 {
+			var err error
+			if changes, err = r.createsToUpdates(ctx, changes); err != nil {
+				return err
+			}
+		}
+		return r.Provider.ApplyChanges(ctx, changes)
+	})
+}
+
+// createsToUpdates moves the creates of records that already exist into the
+// updates of changes.
+func (r *retrying) createsToUpdates(ctx context.Context, changes ChangeSet) (ChangeSet, error) {
+	next := ChangeSet{Updates: slices.Clone(changes.Updates), Deletes: changes.Deletes}
+	for _, rec := range changes.Creates {
+		exists, err := r.Provider.Exists(ctx, rec.Hostname, rec.Type)
+		if err != nil {
+			return changes, err
+		}
+		if exists {
+			next.Updates = append(next.Updates, rec)
+		} else {
+			next.Creates = append(next.Creates, rec)
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Swappable is a Provider that forwards every call to another provider, which
+// can be replaced while the controllers run, e.g. after the provider config was
+// reloaded. Calls already in flight finish on the provider they started on.
+type Swappable struct {
+	current atomic.Pointer[Provider]
+	zones   atomic.Pointer[Zones] // last zones set, passed on to swapped in providers
+}
+
+// NewSwappable returns a Swappable forwarding to p.
+func NewSwappable(p Provider) *Swappable {
+	s := &Swappable{}
+	s.Swap(p)
+	return s
+}
+
+// Swap makes s forward to p from now on. If p is a ZoneSetter, it gets the
+// zones last set on s.
+func (s *Swappable) Swap(p Provider) {
+	if z := s.zones.Load(); z != nil {
+		if zs, ok := p.(ZoneSetter); ok {
+			zs.SetZones(*z)
+		}
+	}
+	s.current.Store(&p)
+}
+
+// SetZones passes the zones to the current provider, if it is a ZoneSetter,
+// and to every provider swapped in later.
+func (s *Swappable) SetZones(z Zones) {
+	s.zones.Store(&z)
+	if zs, ok := s.Current().(ZoneSetter); ok {
+		zs.SetZones(z)
+	}
+}
+
+// Zones returns the zones last set on s.
+func (s *Swappable) Zones() Zones {
+	if z := s.zones.Load(); z != nil {
+		return *z
+	}
+	return Zones{}
+}
+
+// SupportsWildcard reports whether the current provider publishes wildcard
+// records of recordType.
+func (s *Swappable) SupportsWildcard(recordType string) bool {
+	return SupportsWildcard(s.Current(), recordType)
+}
+
+// Current returns the provider s currently forwards to.
+func (s *Swappable) Current() Provider {
+	return *s.current.Load()
+}
+
+func (s *Swappable) Exists(ctx context.Context, hostname, recordType string) (bool, error) {
+	return s.Current().Exists(ctx, hostname, recordType)
+}
+
+func (s *Swappable) List(ctx context.Context, filter ListFilter) ([]Record, error) {
+	return s.Current().List(ctx, filter)
+}
+
+func (s *Swappable) Create(ctx context.Context, record Record) error {
+	return s.Current().Create(ctx, record)
+}
+
+func (s *Swappable) Update(ctx context.Context, record Record) error {
+	return s.Current().Update(ctx, record)
+}
+
+func (s *Swappable) Delete(ctx context.Context, hostname, recordType string) error {
+	return s.Current().Delete(ctx, hostname, recordType)
+}
+
+func (s *Swappable) Upsert(ctx context.Context, record Record) error {
+	return s.Current().Upsert(ctx, record)
+}
+
+func (s *Swappable) ApplyChanges(ctx context.Context, changes ChangeSet) error {
+	return s.Current().ApplyChanges(ctx, changes)
+}
+
+func (s *Swappable) HealthCheck(ctx context.Context) error {
+	return s.Current().HealthCheck(ctx)
+}
@@ -0,0 +1,33 @@
+package dns
+
+import "strings"
+
+// Routes and Ingresses may list wildcard hostnames such as
+// "*.apps.example.com", which stand for every name below "apps.example.com".
+// A provider publishes them with its own wildcard mechanism, e.g. OPNsense
+// writes a host override named "*" in the domain the wildcard covers.
+// Providers report the record types they can publish that way through
+// WildcardSupporter; records of other types are skipped instead of being
+// written under a name that is literally "*".
+
+// IsWildcard reports whether hostname is a wildcard: a "*" label followed by
+// the domain it covers.
+// e.g. "*.apps.example.com" → true, "apps.example.com" → false
+func IsWildcard(hostname string) bool {
+	return strings.HasPrefix(hostname, "*.") && len(strings.TrimSuffix(hostname, ".")) > len("*.")
+}
+
+// WildcardSupporter is implemented by providers that publish wildcard
+// hostnames.
+type WildcardSupporter interface {
+	// SupportsWildcard reports whether wildcard records of recordType can
+	// be written.
+	SupportsWildcard(recordType string) bool
+}
+
+// SupportsWildcard reports whether p publishes wildcard records of recordType.
+// Providers that don't implement WildcardSupporter publish none.
+func SupportsWildcard(p Provider, recordType string) bool {
+	ws, ok := p.(WildcardSupporter)
+	return ok && ws.SupportsWildcard(recordType)
+}
@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsWildcard(t *testing.T) {
+	tests := map[string]bool{
+		"*.apps.example.com": true,
+		"*.example.com.":     true,
+		"apps.example.com":   false,
+		"*":                  false,
+		"*.":                 false,
+		"a.*.example.com":    false,
+		"*app.example.com":   false,
+		"":                   false,
+	}
+	for hostname, want := range tests {
+		if got := IsWildcard(hostname); got != want {
+			t.Errorf("IsWildcard(%q) = %v, want %v", hostname, got, want)
+		}
+	}
+}
+
+type wildcardProvider struct {
+	Provider
+	types []string
+}
+
+func (p wildcardProvider) SupportsWildcard(recordType string) bool {
+	return slices.Contains(p.types, recordType)
+}
+
+func TestSupportsWildcard(t *testing.T) {
+	p := wildcardProvider{types: []string{"A"}}
+	if !SupportsWildcard(p, "A") || SupportsWildcard(p, "CNAME") {
+		t.Error("expected the provider's answer for each record type")
+	}
+	if SupportsWildcard(wildcardProvider{}.Provider, "A") {
+		t.Error("expected providers without WildcardSupporter to publish no wildcards")
+	}
+	s := NewSwappable(p)
+	if !s.SupportsWildcard("A") {
+		t.Error("expected Swappable to forward to the current provider")
+	}
+	s.Swap(struct{ Provider }{})
+	if s.SupportsWildcard("A") {
+		t.Error("expected Swappable to forward to the swapped in provider")
+	}
+}
@@ -0,0 +1,138 @@
+package dns
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// Providers such as OPNsense store a record as a host name inside a domain,
+// where the domain is the zone the DNS server is authoritative for. A hostname
+// is split at the longest zone it falls in, e.g. "a.b.example.com" in zone
+// "example.com" is host "a.b". Hostnames outside every known zone fall back to
+// SplitHostname's first-label split.
+
+// idnaProfile converts internationalized labels to punycode. Unlike
+// idna.Lookup it accepts "*" and "_" labels, which appear in wildcard and
+// service hostnames.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// NormalizeHostname returns a hostname in the form records are compared and
+// stored in: lower case, without a trailing dot, with internationalized labels
+// in punycode.
+// e.g. "Bücher.Example.com." → "xn--bcher-kva.example.com"
+func NormalizeHostname(hostname string) (string, error) {
+	hostname = strings.TrimSuffix(hostname, ".")
+	if isASCII(hostname) {
+		return strings.ToLower(hostname), nil
+	}
+	ascii, err := idnaProfile.ToASCII(hostname)
+	if err != nil {
+		return "", fmt.Errorf("invalid hostname %q: %w", hostname, err)
+	}
+	return ascii, nil
+}
+
+// CanonicalHostname is like NormalizeHostname, but returns hostnames that
+// can't be converted to punycode in lower case instead of failing. Use it to
+// compare hostnames.
+func CanonicalHostname(hostname string) string {
+	normalized, err := NormalizeHostname(hostname)
+	if err != nil {
+		return strings.ToLower(strings.TrimSuffix(hostname, "."))
+	}
+	return normalized
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Zones is a set of DNS zones hostnames are split on. The zero value holds no
+// zones and splits every hostname at its first label.
+type Zones struct {
+	names []string // normalized, longest first
+}
+
+// NewZones returns the zones with the given names, which are normalized and
+// de-duplicated. Names must be plain domains, without wildcards.
+func NewZones(names ...string) (Zones, error) {
+	var z Zones
+	for _, name := range names {
+		normalized, err := NormalizeHostname(strings.TrimSpace(name))
+		if err != nil {
+			return Zones{}, fmt.Errorf("zone: %w", err)
+		}
+		if normalized == "" || strings.Contains(normalized, "*") || strings.HasPrefix(normalized, ".") || strings.Contains(normalized, "..") {
+			return Zones{}, fmt.Errorf("zone: invalid zone name %q", name)
+		}
+		if !slices.Contains(z.names, normalized) {
+			z.names = append(z.names, normalized)
+		}
+	}
+	// Longer zones first, so the first match is the most specific one.
+	slices.SortFunc(z.names, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	return z, nil
+}
+
+// Names returns the zones, longest first.
+func (z Zones) Names() []string {
+	return slices.Clone(z.names)
+}
+
+// Zone returns the zone hostname falls in: the longest zone that is the
+// hostname itself or one of its parent domains.
+func (z Zones) Zone(hostname string) (string, bool) {
+	return z.zoneOf(CanonicalHostname(hostname))
+}
+
+// zoneOf is Zone for a canonical hostname.
+func (z Zones) zoneOf(hostname string) (string, bool) {
+	for _, zone := range z.names {
+		if hostname == zone || len(hostname) > len(zone)+1 && strings.HasSuffix(hostname, "."+zone) {
+			return zone, true
+		}
+	}
+	return "", false
+}
+
+// Split splits a hostname into the labels below its zone and the zone, both
+// normalized. The apex of a zone has an empty host. Hostnames outside every
+// zone are split at the first label like SplitHostname.
+// e.g. with zone "example.com":
+//
+//	"a.b.example.com" → ("a.b", "example.com")
+//	"example.com"     → ("", "example.com")
+//	"app.other.org"   → ("app", "other.org")
+func (z Zones) Split(hostname string) (host, zone string) {
+	hostname = CanonicalHostname(hostname)
+	zone, ok := z.zoneOf(hostname)
+	if !ok {
+		host, zone, _ = strings.Cut(hostname, ".")
+		return host, zone
+	}
+	if hostname == zone {
+		return "", zone
+	}
+	return strings.TrimSuffix(hostname, "."+zone), zone
+}
+
+// ZoneSetter is implemented by providers that split hostnames into zones. The
+// controller passes them the zones from the provider config or the domain map
+// at startup and after every config reload.
+type ZoneSetter interface {
+	SetZones(Zones)
+}
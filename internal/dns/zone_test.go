@@ -0,0 +1,142 @@
+package dns
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"lower case", "App.Example.COM", "app.example.com", false},
+		{"trailing dot", "app.example.com.", "app.example.com", false},
+		{"unicode label", "Bücher.example.com", "xn--bcher-kva.example.com", false},
+		{"punycode kept", "XN--BCHER-KVA.example.com", "xn--bcher-kva.example.com", false},
+		{"unicode wildcard", "*.bücher.example", "*.xn--bcher-kva.example", false},
+		{"fullwidth dot", "app。bücher.example", "app.xn--bcher-kva.example", false},
+		{"service label", "_sip._tcp.example.com", "_sip._tcp.example.com", false},
+		{"invalid unicode", "a‍b.example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeHostname(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizeHostname(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewZones(t *testing.T) {
+	zones, err := NewZones("example.com", "lan", "b.example.com", "Example.com.", "bücher.example")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"xn--bcher-kva.example", "b.example.com", "example.com", "lan"}
+	if got := zones.Names(); !slices.Equal(got, want) {
+		t.Errorf("expected zones %v, got %v", want, got)
+	}
+
+	for _, name := range []string{"", "*.example.com", ".example.com", "a..example.com", "a‍b.example"} {
+		if _, err := NewZones(name); err == nil {
+			t.Errorf("expected error for zone %q", name)
+		}
+	}
+}
+
+func TestZonesSplit(t *testing.T) {
+	zones, err := NewZones("example.com", "b.example.com", "lan", "bücher.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		zones    Zones
+		hostname string
+		host     string
+		zone     string
+	}{
+		{"first label in zone", zones, "app.example.com", "app", "example.com"},
+		{"several labels in zone", zones, "a.x.example.com", "a.x", "example.com"},
+		{"longest zone wins", zones, "a.b.example.com", "a", "b.example.com"},
+		{"zone apex", zones, "example.com", "", "example.com"},
+		{"nested zone apex", zones, "b.example.com", "", "b.example.com"},
+		{"case and trailing dot", zones, "App.Example.COM.", "app", "example.com"},
+		{"single label zone", zones, "nas.lan", "nas", "lan"},
+		{"unicode hostname", zones, "shop.Bücher.example", "shop", "xn--bcher-kva.example"},
+		{"punycode hostname", zones, "shop.xn--bcher-kva.example", "shop", "xn--bcher-kva.example"},
+		{"wildcard", zones, "*.apps.example.com", "*.apps", "example.com"},
+		{"suffix but not a label boundary", zones, "app.myexample.com", "app", "myexample.com"},
+		{"outside every zone", zones, "a.b.example.org", "a", "b.example.org"},
+		{"single label outside every zone", zones, "localhost", "localhost", ""},
+		{"no zones", Zones{}, "a.b.example.com", "a", "b.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, zone := tt.zones.Split(tt.hostname)
+			if host != tt.host || zone != tt.zone {
+				t.Errorf("Split(%q) = (%q, %q), want (%q, %q)", tt.hostname, host, zone, tt.host, tt.zone)
+			}
+		})
+	}
+}
+
+func FuzzZonesSplit(f *testing.F) {
+	zones, err := NewZones("example.com", "b.example.com", "lan", "bücher.example")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, seed := range []string{"app.example.com", "a.b.example.com", "example.com", "nas.lan", "shop.bücher.example", "*.example.com", "localhost", "", ".", "a..example.com", "EXAMPLE.COM.", ".lan", "00000.."} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, hostname string) {
+		host, zone := zones.Split(hostname)
+		canonical := CanonicalHostname(hostname)
+
+		known, inZone := zones.Zone(hostname)
+		switch {
+		case inZone:
+			// The zone is the longest one containing the hostname, and the
+			// host joins back onto it, with an empty host at the apex.
+			if zone != known {
+				t.Fatalf("Split(%q) zone = %q, want %q", hostname, zone, known)
+			}
+			for _, other := range zones.Names() {
+				if len(other) > len(zone) && (canonical == other || strings.HasSuffix(canonical, "."+other)) {
+					t.Fatalf("Split(%q) zone = %q, but %q is longer", hostname, zone, other)
+				}
+			}
+			joined := zone
+			if host != "" {
+				joined = host + "." + zone
+			}
+			if joined != canonical {
+				t.Fatalf("Split(%q) = (%q, %q), which joins to %q, want %q", hostname, host, zone, joined, canonical)
+			}
+		case !strings.Contains(canonical, "."):
+			if host != canonical || zone != "" {
+				t.Fatalf("Split(%q) = (%q, %q), want the hostname without a zone", hostname, host, zone)
+			}
+		default:
+			// Outside every zone, the first label is split off.
+			if strings.Contains(host, ".") || host+"."+zone != canonical {
+				t.Fatalf("Split(%q) = (%q, %q), want the first label split off %q", hostname, host, zone, canonical)
+			}
+		}
+	})
+}
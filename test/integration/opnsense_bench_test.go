@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
+)
+
+// BenchmarkReconcileHostnames reconciles 100 hostnames against a firewall with
+// 2000 host overrides the way the route controller does, checking each record
+// and upserting it unchanged, with and without the override cache.
+func BenchmarkReconcileHostnames(b *testing.B) {
+	for _, bench := range []struct {
+		name     string
+		cacheTTL string
+	}{
+		{"cached", "1m"},
+		{"uncached", "0"},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			fake := newFakeOPNsense()
+			fake.seed(2000, "example.com", "prod/default/web")
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			p := newProviderWith(b, logr.Discard(), srv.URL, map[string]string{"cache_ttl": bench.cacheTTL})
+			ctx := context.Background()
+			meta := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/web"}
+
+			for b.Loop() {
+				for i := range 100 {
+					rec := dns.Record{
+						Hostname: fmt.Sprintf("host-%d.example.com", i),
+						Type:     "A",
+						Values:   []string{fmt.Sprintf("10.0.%d.%d", i/256, i%256)},
+						Meta:     meta,
+					}
+					if _, err := p.Exists(ctx, rec.Hostname, rec.Type); err != nil {
+						b.Fatalf("Exists: %v", err)
+					}
+					if err := p.Upsert(ctx, rec); err != nil {
+						b.Fatalf("Upsert: %v", err)
+					}
+				}
+			}
+			b.ReportMetric(float64(fake.searches())/float64(b.N), "searches/op")
+		})
+	}
+}
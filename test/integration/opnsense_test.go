@@ -3,14 +3,21 @@ package integration
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	logrtesting "github.com/go-logr/logr/testing"
 
 	"github.com/yuriy-kovalchuk/yk-dns-manager/internal/dns"
@@ -19,10 +26,13 @@ import (
 
 // fakeOPNsense is a minimal in-memory OPNsense Unbound API for testing.
 type fakeOPNsense struct {
-	mu     sync.Mutex
-	store  map[string]hostOverride
-	nextID int
-	calls  []string // tracks endpoint calls in order
+	mu      sync.Mutex
+	store   map[string]hostOverride
+	aliases map[string]hostAlias
+	nextID  int
+	calls   []string // tracks endpoint calls in order
+	noTTL   bool     // behave like OPNsense versions without host override TTLs
+	total   int      // total reported by searches instead of the row count, when set
 }
 
 type hostOverride struct {
@@ -31,13 +41,24 @@ type hostOverride struct {
 	Domain      string `json:"domain"`
 	RR          string `json:"rr"`
 	Server      string `json:"server"`
+	TTL         string `json:"ttl,omitempty"`
 	Description string `json:"description"`
 	MXPrio      string `json:"mxprio"`
 	MX          string `json:"mx"`
 }
 
+// hostAlias is a host alias as written by the API. Host holds the UUID of the
+// host override it is attached to.
+type hostAlias struct {
+	Enabled     string `json:"enabled"`
+	Host        string `json:"host"`
+	Hostname    string `json:"hostname"`
+	Domain      string `json:"domain"`
+	Description string `json:"description"`
+}
+
 func newFakeOPNsense() *fakeOPNsense {
-	return &fakeOPNsense{store: map[string]hostOverride{}}
+	return &fakeOPNsense{store: map[string]hostOverride{}, aliases: map[string]hostAlias{}}
 }
 
 func (f *fakeOPNsense) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -48,12 +69,22 @@ func (f *fakeOPNsense) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case r.URL.Path == "/api/unbound/settings/searchHostOverride":
 		f.handleSearch(w, r)
+	case r.URL.Path == "/api/unbound/settings/getHostOverride":
+		f.handleGet(w, r)
 	case r.URL.Path == "/api/unbound/settings/addHostOverride":
 		f.handleAdd(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/unbound/settings/setHostOverride/"):
 		f.handleSet(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/unbound/settings/delHostOverride/"):
 		f.handleDel(w, r)
+	case r.URL.Path == "/api/unbound/settings/searchHostAlias":
+		f.handleSearchAlias(w, r)
+	case r.URL.Path == "/api/unbound/settings/addHostAlias":
+		f.handleAddAlias(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/unbound/settings/setHostAlias/"):
+		f.handleSetAlias(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/unbound/settings/delHostAlias/"):
+		f.handleDelAlias(w, r)
 	case r.URL.Path == "/api/unbound/service/reconfigure":
 		f.handleReconfigure(w, r)
 	default:
@@ -61,7 +92,9 @@ func (f *fakeOPNsense) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (f *fakeOPNsense) handleSearch(w http.ResponseWriter, _ *http.Request) {
+// handleSearch lists overrides ordered by UUID, one page at a time when the
+// current and rowCount parameters are given.
+func (f *fakeOPNsense) handleSearch(w http.ResponseWriter, r *http.Request) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -73,7 +106,74 @@ func (f *fakeOPNsense) handleSearch(w http.ResponseWriter, _ *http.Request) {
 	for id, h := range f.store {
 		rows = append(rows, row{UUID: id, hostOverride: h})
 	}
-	writeJSON(w, map[string]interface{}{"rows": rows, "total": len(rows)})
+	slices.SortFunc(rows, func(a, b row) int { return strings.Compare(a.UUID, b.UUID) })
+	total := len(rows)
+
+	current, _ := strconv.Atoi(r.URL.Query().Get("current"))
+	rowCount, _ := strconv.Atoi(r.URL.Query().Get("rowCount"))
+	if current > 0 && rowCount > 0 {
+		start := min((current-1)*rowCount, total)
+		rows = rows[start:min(start+rowCount, total)]
+	}
+	if f.total > 0 {
+		total = f.total
+	}
+	writeJSON(w, map[string]interface{}{"rows": rows, "total": total})
+}
+
+// seed adds n host overrides owned by owner, named host-0.domain and so on.
+func (f *fakeOPNsense) seed(n int, domain, owner string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range n {
+		f.nextID++
+		f.store[fmt.Sprintf("uuid-%d", f.nextID)] = hostOverride{
+			Enabled:     "1",
+			Hostname:    fmt.Sprintf("host-%d", i),
+			Domain:      domain,
+			RR:          "A",
+			Server:      fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			TTL:         "300",
+			Description: dns.ManagedDescription + " [owner=" + owner + "]",
+		}
+	}
+}
+
+// searches returns the number of host override searches made so far.
+func (f *fakeOPNsense) searches() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == "GET /api/unbound/settings/searchHostOverride" {
+			n++
+		}
+	}
+	return n
+}
+
+// handleGet returns the defaults of a new host override, which only have a ttl
+// field on versions that support it.
+func (f *fakeOPNsense) handleGet(w http.ResponseWriter, _ *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	host := map[string]string{"enabled": "1", "hostname": "", "domain": "", "rr": "A", "server": "", "description": ""}
+	if !f.noTTL {
+		host["ttl"] = ""
+	}
+	writeJSON(w, map[string]interface{}{"host": host})
+}
+
+// checkTTL rejects a ttl field the fake's version doesn't have.
+func (f *fakeOPNsense) checkTTL(w http.ResponseWriter, h hostOverride) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.noTTL && h.TTL != "" {
+		http.Error(w, `{"result":"failed","validations":{"host.ttl":"unknown field"}}`, http.StatusBadRequest)
+		return false
+	}
+	return true
 }
 
 func (f *fakeOPNsense) handleAdd(w http.ResponseWriter, r *http.Request) {
@@ -84,6 +184,9 @@ func (f *fakeOPNsense) handleAdd(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !f.checkTTL(w, payload.Host) {
+		return
+	}
 
 	f.mu.Lock()
 	f.nextID++
@@ -103,6 +206,9 @@ func (f *fakeOPNsense) handleSet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !f.checkTTL(w, payload.Host) {
+		return
+	}
 
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -127,6 +233,81 @@ func (f *fakeOPNsense) handleDel(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"result": "deleted"})
 }
 
+// handleSearchAlias lists aliases like OPNsense does, with host shown as the
+// FQDN of the host override the alias is attached to.
+func (f *fakeOPNsense) handleSearchAlias(w http.ResponseWriter, _ *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type row struct {
+		UUID string `json:"uuid"`
+		hostAlias
+	}
+	rows := []row{}
+	for id, a := range f.aliases {
+		if h, ok := f.store[a.Host]; ok {
+			a.Host = h.Hostname + "." + h.Domain
+		}
+		rows = append(rows, row{UUID: id, hostAlias: a})
+	}
+	writeJSON(w, map[string]interface{}{"rows": rows, "total": len(rows)})
+}
+
+func (f *fakeOPNsense) handleAddAlias(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Alias hostAlias `json:"alias"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.store[payload.Alias.Host]; !ok {
+		writeJSON(w, map[string]interface{}{"result": "failed", "validations": map[string]string{"alias.host": "host not found"}})
+		return
+	}
+	f.nextID++
+	id := fmt.Sprintf("uuid-%d", f.nextID)
+	f.aliases[id] = payload.Alias
+
+	writeJSON(w, map[string]string{"result": "saved", "uuid": id})
+}
+
+func (f *fakeOPNsense) handleSetAlias(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/unbound/settings/setHostAlias/")
+	var payload struct {
+		Alias hostAlias `json:"alias"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.aliases[id]; !ok {
+		http.Error(w, `{"result":"not found"}`, http.StatusNotFound)
+		return
+	}
+	f.aliases[id] = payload.Alias
+	writeJSON(w, map[string]string{"result": "saved"})
+}
+
+func (f *fakeOPNsense) handleDelAlias(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/unbound/settings/delHostAlias/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.aliases[id]; !ok {
+		http.Error(w, `{"result":"not found"}`, http.StatusNotFound)
+		return
+	}
+	delete(f.aliases, id)
+	writeJSON(w, map[string]string{"result": "deleted"})
+}
+
 func (f *fakeOPNsense) handleReconfigure(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, map[string]string{"status": "ok"})
 }
@@ -146,11 +327,19 @@ func readJSON(r *http.Request, v interface{}) error {
 
 func newProvider(t *testing.T, serverURL string) *opnsense.Provider {
 	t.Helper()
-	p, err := opnsense.New(logrtesting.NewTestLogger(t), map[string]string{
+	return newProviderWith(t, logrtesting.NewTestLogger(t), serverURL, nil)
+}
+
+// newProviderWith creates a provider with extra settings, such as cache_ttl.
+func newProviderWith(t testing.TB, log logr.Logger, serverURL string, extra map[string]string) *opnsense.Provider {
+	t.Helper()
+	settings := map[string]string{
 		"base_url":   serverURL + "/api",
 		"api_key":    "test-key",
 		"api_secret": "test-secret",
-	})
+	}
+	maps.Copy(settings, extra)
+	p, err := opnsense.New(log, settings)
 	if err != nil {
 		t.Fatalf("failed to create provider: %v", err)
 	}
@@ -178,7 +367,7 @@ func TestCreateAndExists(t *testing.T) {
 	err = p.Create(ctx, dns.Record{
 		Hostname: "app.example.com",
 		Type:     "A",
-		Value:    "10.0.0.1",
+		Values:   []string{"10.0.0.1"},
 		Meta:     map[string]string{"description": "test record"},
 	})
 	if err != nil {
@@ -234,7 +423,7 @@ func TestUpdateExistingRecord(t *testing.T) {
 	err := p.Create(ctx, dns.Record{
 		Hostname: "app.example.com",
 		Type:     "A",
-		Value:    "10.0.0.1",
+		Values:   []string{"10.0.0.1"},
 	})
 	if err != nil {
 		t.Fatalf("Create: %v", err)
@@ -244,7 +433,7 @@ func TestUpdateExistingRecord(t *testing.T) {
 	err = p.Update(ctx, dns.Record{
 		Hostname: "app.example.com",
 		Type:     "A",
-		Value:    "10.0.0.2",
+		Values:   []string{"10.0.0.2"},
 	})
 	if err != nil {
 		t.Fatalf("Update: %v", err)
@@ -271,7 +460,7 @@ func TestUpdateNonExistent(t *testing.T) {
 	err := p.Update(ctx, dns.Record{
 		Hostname: "ghost.example.com",
 		Type:     "A",
-		Value:    "10.0.0.1",
+		Values:   []string{"10.0.0.1"},
 	})
 	if err == nil {
 		t.Fatal("expected error when updating non-existent record")
@@ -290,7 +479,7 @@ func TestDeleteExistingRecord(t *testing.T) {
 	err := p.Create(ctx, dns.Record{
 		Hostname: "app.example.com",
 		Type:     "A",
-		Value:    "10.0.0.1",
+		Values:   []string{"10.0.0.1"},
 	})
 	if err != nil {
 		t.Fatalf("Create: %v", err)
@@ -343,7 +532,7 @@ func TestUpsertCreatesAndUpdates(t *testing.T) {
 	err := p.Upsert(ctx, dns.Record{
 		Hostname: "app.example.com",
 		Type:     "A",
-		Value:    "10.0.0.1",
+		Values:   []string{"10.0.0.1"},
 	})
 	if err != nil {
 		t.Fatalf("Upsert (create): %v", err)
@@ -359,7 +548,7 @@ func TestUpsertCreatesAndUpdates(t *testing.T) {
 	err = p.Upsert(ctx, dns.Record{
 		Hostname: "app.example.com",
 		Type:     "A",
-		Value:    "10.0.0.99",
+		Values:   []string{"10.0.0.99"},
 	})
 	if err != nil {
 		t.Fatalf("Upsert (update): %v", err)
@@ -398,7 +587,7 @@ func TestFullLifecycle(t *testing.T) {
 	err = p.Create(ctx, dns.Record{
 		Hostname: "web.mysite.org",
 		Type:     "A",
-		Value:    "192.168.1.10",
+		Values:   []string{"192.168.1.10"},
 		Meta:     map[string]string{"description": "web server"},
 	})
 	if err != nil {
@@ -418,7 +607,7 @@ func TestFullLifecycle(t *testing.T) {
 	err = p.Update(ctx, dns.Record{
 		Hostname: "web.mysite.org",
 		Type:     "A",
-		Value:    "192.168.1.20",
+		Values:   []string{"192.168.1.20"},
 	})
 	if err != nil {
 		t.Fatalf("step 4 Update: %v", err)
@@ -462,9 +651,9 @@ func TestMultipleRecords(t *testing.T) {
 	ctx := context.Background()
 
 	records := []dns.Record{
-		{Hostname: "app.example.com", Type: "A", Value: "10.0.0.1"},
-		{Hostname: "api.example.com", Type: "A", Value: "10.0.0.2"},
-		{Hostname: "db.other.net", Type: "A", Value: "10.0.0.3"},
+		{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}},
+		{Hostname: "api.example.com", Type: "A", Values: []string{"10.0.0.2"}},
+		{Hostname: "db.other.net", Type: "A", Values: []string{"10.0.0.3"}},
 	}
 
 	for _, rec := range records {
@@ -508,3 +697,893 @@ func TestMultipleRecords(t *testing.T) {
 		t.Error("db.other.net should still exist")
 	}
 }
+
+func TestRoundRobinRecordSet(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	rec := dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}}
+	if err := p.Create(ctx, rec); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	fake.mu.Lock()
+	if len(fake.store) != 3 {
+		t.Fatalf("expected one override per value, got %d", len(fake.store))
+	}
+	fake.mu.Unlock()
+
+	records, err := p.List(ctx, dns.ListFilter{Hostname: "app.example.com"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 || !dns.EqualValues(records[0].Values, rec.Values) {
+		t.Fatalf("expected a single record with all values, got %+v", records)
+	}
+
+	// Replacing one value removes its override and adds the new one, leaving
+	// the others in place.
+	rec.Values = []string{"10.0.0.1", "10.0.0.3", "10.0.0.4"}
+	if err := p.Update(ctx, rec); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	fake.mu.Lock()
+	var servers []string
+	for id, h := range fake.store {
+		servers = append(servers, h.Server)
+		if h.Server == "10.0.0.2" {
+			t.Errorf("expected override %s for the removed value to be deleted", id)
+		}
+	}
+	if !dns.EqualValues(servers, rec.Values) || len(servers) != 3 {
+		t.Errorf("expected overrides for %v, got %v", rec.Values, servers)
+	}
+	if h, ok := fake.store["uuid-1"]; !ok || h.Server != "10.0.0.1" {
+		t.Errorf("expected the override for an unchanged value to be kept, got %+v", fake.store)
+	}
+	fake.mu.Unlock()
+
+	if err := p.Delete(ctx, "app.example.com", "A"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.store) != 0 {
+		t.Errorf("expected every override to be deleted, got %d", len(fake.store))
+	}
+}
+
+func TestApplyChangesReconfiguresOnce(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	if err := p.Create(ctx, dns.Record{Hostname: "old.example.com", Type: "A", Values: []string{"10.0.0.1"}}); err != nil {
+		t.Fatalf("Create old: %v", err)
+	}
+	if err := p.Create(ctx, dns.Record{Hostname: "api.example.com", Type: "A", Values: []string{"10.0.0.1"}}); err != nil {
+		t.Fatalf("Create api: %v", err)
+	}
+
+	fake.mu.Lock()
+	fake.calls = nil
+	fake.mu.Unlock()
+	var observed []error
+	p.ObserveReconfigures(func(err error) { observed = append(observed, err) })
+
+	err := p.ApplyChanges(ctx, dns.ChangeSet{
+		Creates: []dns.Record{
+			{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.2"}},
+			{Hostname: "web.example.com", Type: "A", Values: []string{"10.0.0.3"}},
+		},
+		Updates: []dns.Record{
+			{Hostname: "api.example.com", Type: "A", Values: []string{"10.0.0.9"}},
+		},
+		Deletes: []dns.Record{
+			{Hostname: "old.example.com", Type: "A"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	reconfigures, searches := 0, 0
+	for _, c := range fake.calls {
+		switch c {
+		case "POST /api/unbound/service/reconfigure":
+			reconfigures++
+		case "GET /api/unbound/settings/searchHostOverride":
+			searches++
+		}
+	}
+	if reconfigures != 1 {
+		t.Errorf("expected 1 reconfigure, got %d", reconfigures)
+	}
+	if len(observed) != 1 || observed[0] != nil {
+		t.Errorf("expected 1 successful reconfigure to be observed, got %v", observed)
+	}
+	if searches != 1 {
+		t.Errorf("expected 1 search, got %d", searches)
+	}
+
+	if len(fake.store) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(fake.store))
+	}
+	for _, h := range fake.store {
+		if h.Hostname == "old" {
+			t.Error("old.example.com should have been deleted")
+		}
+		if h.Hostname == "api" && h.Server != "10.0.0.9" {
+			t.Errorf("expected api server '10.0.0.9', got %q", h.Server)
+		}
+	}
+}
+
+func TestUpsertUnchangedSkipsWrite(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	meta := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/web"}
+	app := dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1", "10.0.0.2"}, Meta: meta}
+	alias := dns.Record{Hostname: "www.example.com", Type: "CNAME", Values: []string{"app.example.com"}, Meta: meta}
+	if err := p.ApplyChanges(ctx, dns.ChangeSet{Creates: []dns.Record{app, alias}}); err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	writes := func() (sets, reconfigures int) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		for _, c := range fake.calls {
+			switch {
+			case strings.Contains(c, "/setHost"), strings.Contains(c, "/addHost"), strings.Contains(c, "/delHost"):
+				sets++
+			case c == "POST /api/unbound/service/reconfigure":
+				reconfigures++
+			}
+		}
+		fake.calls = nil
+		return sets, reconfigures
+	}
+	writes()
+
+	// Values in another order are still the same record set.
+	app.Values = []string{"10.0.0.2", "10.0.0.1"}
+	if err := p.Upsert(ctx, app); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := p.Update(ctx, alias); err != nil {
+		t.Fatalf("Update alias: %v", err)
+	}
+	if sets, reconfigures := writes(); sets != 0 || reconfigures != 0 {
+		t.Errorf("expected no writes and no reconfigure for unchanged records, got %d writes and %d reconfigures", sets, reconfigures)
+	}
+
+	// A change of TTL alone rewrites both overrides.
+	app.TTL = 60
+	if err := p.Upsert(ctx, app); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if sets, reconfigures := writes(); sets != 2 || reconfigures != 1 {
+		t.Errorf("expected 2 writes and 1 reconfigure for a TTL change, got %d writes and %d reconfigures", sets, reconfigures)
+	}
+}
+
+func TestApplyChangesEmpty(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+
+	if err := p.ApplyChanges(context.Background(), dns.ChangeSet{}); err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no API calls for empty change set, got %v", fake.calls)
+	}
+}
+
+func TestListRecords(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	records := []dns.Record{
+		{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}, Meta: map[string]string{"description": "app"}},
+		{Hostname: "app.example.com", Type: "AAAA", Values: []string{"fd00::1"}},
+		{Hostname: "db.other.net", Type: "A", Values: []string{"10.0.0.3"}},
+	}
+	for _, rec := range records {
+		if err := p.Create(ctx, rec); err != nil {
+			t.Fatalf("Create %s: %v", rec.Hostname, err)
+		}
+	}
+
+	all, err := p.List(ctx, dns.ListFilter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(all))
+	}
+
+	got, err := p.List(ctx, dns.ListFilter{Domain: "example.com", Type: "A"})
+	if err != nil {
+		t.Fatalf("List filtered: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(got))
+	}
+	rec := got[0]
+	if rec.Hostname != "app.example.com" {
+		t.Errorf("expected hostname 'app.example.com', got %q", rec.Hostname)
+	}
+	if !slices.Equal(rec.Values, []string{"10.0.0.1"}) {
+		t.Errorf("expected value '10.0.0.1', got %q", rec.Values)
+	}
+	if rec.Meta["description"] != "app" {
+		t.Errorf("expected description 'app', got %q", rec.Meta["description"])
+	}
+	if rec.Meta["uuid"] == "" {
+		t.Error("expected uuid in record meta")
+	}
+}
+
+func TestRecordTTL(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	err := p.ApplyChanges(ctx, dns.ChangeSet{Creates: []dns.Record{
+		{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}, TTL: 60},
+		{Hostname: "web.example.com", Type: "A", Values: []string{"10.0.0.2"}},
+	}})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	fake.mu.Lock()
+	for _, h := range fake.store {
+		want := map[string]string{"app": "60", "web": "300"}[h.Hostname]
+		if h.TTL != want {
+			t.Errorf("expected %s TTL %q, got %q", h.Hostname, want, h.TTL)
+		}
+	}
+	fake.mu.Unlock()
+
+	got, err := p.List(ctx, dns.ListFilter{Hostname: "app.example.com"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 1 || got[0].TTL != 60 {
+		t.Fatalf("expected app.example.com listed with TTL 60, got %+v", got)
+	}
+
+	// A change of TTL alone is written as well.
+	err = p.ApplyChanges(ctx, dns.ChangeSet{Updates: []dns.Record{
+		{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}, TTL: 3600},
+	}})
+	if err != nil {
+		t.Fatalf("ApplyChanges update: %v", err)
+	}
+	got, err = p.List(ctx, dns.ListFilter{Hostname: "app.example.com"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 1 || got[0].TTL != 3600 {
+		t.Errorf("expected app.example.com listed with TTL 3600, got %+v", got)
+	}
+}
+
+func TestRecordTTLUnsupported(t *testing.T) {
+	fake := newFakeOPNsense()
+	fake.noTTL = true
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	if err := p.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	// The fake rejects a ttl field, like versions without host override TTLs.
+	if err := p.Create(ctx, dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}, TTL: 60}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := p.List(ctx, dns.ListFilter{Hostname: "app.example.com"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 1 || got[0].TTL != 0 {
+		t.Errorf("expected app.example.com listed without a TTL, got %+v", got)
+	}
+}
+
+func TestZoneSplit(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	zones, err := dns.NewZones("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetZones(zones)
+	ctx := context.Background()
+
+	// An override written before zones were known, split at the first label.
+	fake.mu.Lock()
+	fake.store["uuid-legacy"] = hostOverride{
+		Enabled: "1", Hostname: "api", Domain: "v1.example.com", RR: "A", Server: "10.0.0.9",
+		Description: dns.ManagedDescription + " [owner=prod/default/api]",
+	}
+	fake.mu.Unlock()
+
+	owner := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/api"}
+	err = p.ApplyChanges(ctx, dns.ChangeSet{
+		Creates: []dns.Record{
+			{Hostname: "a.b.example.com", Type: "A", Values: []string{"10.0.0.1"}, Meta: owner},
+			{Hostname: "Example.com.", Type: "A", Values: []string{"10.0.0.2"}, Meta: owner},
+			{Hostname: "shop.bücher.example.com", Type: "A", Values: []string{"10.0.0.3"}, Meta: owner},
+		},
+		Updates: []dns.Record{
+			{Hostname: "api.v1.example.com", Type: "A", Values: []string{"10.0.0.10"}, Meta: owner},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	fake.mu.Lock()
+	got := map[string]string{}
+	for _, h := range fake.store {
+		got[h.Server] = h.Hostname + " | " + h.Domain
+	}
+	fake.mu.Unlock()
+	want := map[string]string{
+		"10.0.0.1":  "a.b | example.com",
+		"10.0.0.2":  " | example.com",
+		"10.0.0.3":  "shop.xn--bcher-kva | example.com",
+		"10.0.0.10": "api.v1 | example.com",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("expected overrides %v, got %v", want, got)
+	}
+
+	for _, hostname := range []string{"a.b.example.com", "example.com", "shop.xn--bcher-kva.example.com", "api.v1.example.com"} {
+		exists, err := p.Exists(ctx, hostname, "A")
+		if err != nil || !exists {
+			t.Errorf("expected %s to exist, got %v, %v", hostname, exists, err)
+		}
+	}
+
+	// A CNAME can't live at the zone apex.
+	err = p.Create(ctx, dns.Record{Hostname: "example.com", Type: "CNAME", Values: []string{"a.b.example.com"}, Meta: owner})
+	if err == nil || !strings.Contains(err.Error(), "zone apex") {
+		t.Errorf("expected the apex CNAME to be refused, got %v", err)
+	}
+}
+
+func TestWildcardHostname(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	zones, err := dns.NewZones("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetZones(zones)
+	ctx := context.Background()
+
+	owner := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/apps"}
+	err = p.ApplyChanges(ctx, dns.ChangeSet{
+		Creates: []dns.Record{
+			{Hostname: "*.apps.example.com", Type: "A", Values: []string{"10.0.0.1"}, Meta: owner},
+			{Hostname: "*.example.com", Type: "AAAA", Values: []string{"fd00::1"}, Meta: owner},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	fake.mu.Lock()
+	got := map[string]string{}
+	for _, h := range fake.store {
+		got[h.Server] = h.Hostname + " | " + h.Domain
+	}
+	fake.mu.Unlock()
+	// The wildcard is written as "*" in the domain it covers, not split at the zone.
+	want := map[string]string{
+		"10.0.0.1": "* | apps.example.com",
+		"fd00::1":  "* | example.com",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("expected overrides %v, got %v", want, got)
+	}
+
+	records, err := p.List(ctx, dns.ListFilter{Hostname: "*.apps.example.com", Type: "A"})
+	if err != nil || len(records) != 1 || records[0].Hostname != "*.apps.example.com" {
+		t.Errorf("expected the wildcard override to be listed by its hostname, got %v, %v", records, err)
+	}
+
+	if p.SupportsWildcard("CNAME") {
+		t.Error("expected wildcard CNAMEs to be unsupported")
+	}
+	err = p.Create(ctx, dns.Record{Hostname: "*.web.example.com", Type: "CNAME", Values: []string{"*.apps.example.com"}, Meta: owner})
+	if err == nil || !strings.Contains(err.Error(), "wildcard CNAME") {
+		t.Errorf("expected the wildcard CNAME to be refused, got %v", err)
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	fake := newFakeOPNsense()
+	fake.seed(1200, "example.com", "prod/default/web")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+
+	records, err := p.List(context.Background(), dns.ListFilter{Type: "A"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1200 {
+		t.Errorf("expected 1200 records, got %d", len(records))
+	}
+	if got := fake.searches(); got != 3 {
+		t.Errorf("expected 3 pages to be fetched, got %d", got)
+	}
+}
+
+func TestSearchPaginationIgnoresTotal(t *testing.T) {
+	fake := newFakeOPNsense()
+	fake.seed(1200, "example.com", "prod/default/web")
+	fake.total = 500
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+
+	records, err := p.List(context.Background(), dns.ListFilter{Type: "A"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1200 {
+		t.Errorf("expected 1200 records despite the wrong total, got %d", len(records))
+	}
+	if got := fake.searches(); got != 3 {
+		t.Errorf("expected 3 pages to be fetched, got %d", got)
+	}
+}
+
+func TestOverrideCache(t *testing.T) {
+	fake := newFakeOPNsense()
+	fake.seed(10, "example.com", "prod/default/web")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProviderWith(t, logrtesting.NewTestLogger(t), srv.URL, map[string]string{"cache_ttl": "200ms"})
+	ctx := context.Background()
+
+	for _, host := range []string{"host-1.example.com", "host-2.example.com", "HOST-3.example.com"} {
+		exists, err := p.Exists(ctx, host, "A")
+		if err != nil {
+			t.Fatalf("Exists: %v", err)
+		}
+		if !exists {
+			t.Errorf("expected %s to exist", host)
+		}
+	}
+	if got := fake.searches(); got != 1 {
+		t.Errorf("expected lookups to share 1 search, got %d", got)
+	}
+
+	// Our own writes are visible right away.
+	if err := p.Create(ctx, dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.1.1"}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if exists, err := p.Exists(ctx, "app.example.com", "A"); err != nil || !exists {
+		t.Errorf("expected app.example.com to exist after creating it, got %v, %v", exists, err)
+	}
+
+	// Changes made elsewhere show up once the cache expires.
+	fake.mu.Lock()
+	for id, h := range fake.store {
+		if h.Hostname == "host-1" {
+			delete(fake.store, id)
+		}
+	}
+	fake.mu.Unlock()
+	if exists, _ := p.Exists(ctx, "host-1.example.com", "A"); !exists {
+		t.Error("expected the cached table to be used before it expires")
+	}
+	time.Sleep(250 * time.Millisecond)
+	if exists, _ := p.Exists(ctx, "host-1.example.com", "A"); exists {
+		t.Error("expected host-1.example.com to be gone once the cache expired")
+	}
+}
+
+func TestOwnershipProtectsForeignRecords(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	// A hand-made override and one owned by another route.
+	if err := p.Create(ctx, dns.Record{
+		Hostname: "nas.example.com", Type: "A", Values: []string{"10.0.0.5"},
+		Meta: map[string]string{"description": "my NAS"},
+	}); err != nil {
+		t.Fatalf("Create nas: %v", err)
+	}
+	if err := p.Create(ctx, dns.Record{
+		Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"},
+		Meta: map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/other"},
+	}); err != nil {
+		t.Fatalf("Create app: %v", err)
+	}
+
+	owner := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/web"}
+	err := p.ApplyChanges(ctx, dns.ChangeSet{
+		Updates: []dns.Record{{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.9"}, Meta: owner}},
+		Deletes: []dns.Record{{Hostname: "nas.example.com", Type: "A", Meta: owner}},
+		Creates: []dns.Record{{Hostname: "web.example.com", Type: "A", Values: []string{"10.0.0.2"}, Meta: owner}},
+	})
+	if !errors.Is(err, dns.ErrNotOwned) {
+		t.Fatalf("expected ErrNotOwned, got %v", err)
+	}
+
+	records, err := p.List(ctx, dns.ListFilter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records (foreign ones kept, new one created), got %d", len(records))
+	}
+	for _, rec := range records {
+		switch rec.Hostname {
+		case "app.example.com":
+			if !slices.Equal(rec.Values, []string{"10.0.0.1"}) {
+				t.Errorf("expected foreign record value unchanged, got %q", rec.Values)
+			}
+			if rec.Owner() != "prod/default/other" {
+				t.Errorf("expected owner 'prod/default/other', got %q", rec.Owner())
+			}
+		case "web.example.com":
+			if rec.Owner() != "prod/default/web" {
+				t.Errorf("expected owner 'prod/default/web', got %q", rec.Owner())
+			}
+			if rec.Meta["description"] != dns.ManagedDescription {
+				t.Errorf("expected description %q, got %q", dns.ManagedDescription, rec.Meta["description"])
+			}
+		}
+	}
+
+	// The single-record Update path refuses too.
+	err = p.Update(ctx, dns.Record{Hostname: "nas.example.com", Type: "A", Values: []string{"10.0.0.9"}, Meta: owner})
+	if !errors.Is(err, dns.ErrNotOwned) {
+		t.Fatalf("expected ErrNotOwned from Update, got %v", err)
+	}
+}
+
+func TestOwnershipAdoptsLegacyRecords(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	// Records written before owner IDs existed only carry the managed description.
+	if err := p.Create(ctx, dns.Record{
+		Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"},
+		Meta: map[string]string{"description": dns.ManagedDescription},
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	owner := map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/web"}
+	err := p.ApplyChanges(ctx, dns.ChangeSet{
+		Updates: []dns.Record{{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.9"}, Meta: owner}},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for _, h := range fake.store {
+		if h.Server != "10.0.0.9" {
+			t.Errorf("expected server '10.0.0.9', got %q", h.Server)
+		}
+		if h.Description != "managed by yk-dns-manager [owner=prod/default/web]" {
+			t.Errorf("expected owner tag in description, got %q", h.Description)
+		}
+	}
+}
+
+func TestOwnershipHandover(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	if err := p.Create(ctx, dns.Record{
+		Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"},
+		Meta: map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/canary"},
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A handover naming a different previous owner is refused.
+	err := p.ApplyChanges(ctx, dns.ChangeSet{Updates: []dns.Record{{
+		Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"},
+		Meta: map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/stable", dns.MetaPreviousOwner: "prod/default/other"},
+	}}})
+	if !errors.Is(err, dns.ErrNotOwned) {
+		t.Fatalf("expected ErrNotOwned, got %v", err)
+	}
+
+	err = p.ApplyChanges(ctx, dns.ChangeSet{Updates: []dns.Record{{
+		Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"},
+		Meta: map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/stable", dns.MetaPreviousOwner: "prod/default/canary"},
+	}}})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	records, err := p.List(ctx, dns.ListFilter{Hostname: "app.example.com", Type: "A"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 || records[0].Owner() != "prod/default/stable" || !slices.Equal(records[0].Values, []string{"10.0.0.1"}) {
+		t.Fatalf("expected the record handed over to prod/default/stable, got %+v", records)
+	}
+	if records[0].Meta["description"] != dns.ManagedDescription {
+		t.Errorf("expected description %q, got %q", dns.ManagedDescription, records[0].Meta["description"])
+	}
+}
+
+func TestCNAMEAliasLifecycle(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	for _, host := range []string{"gateway", "edge"} {
+		if err := p.Create(ctx, dns.Record{Hostname: host + ".example.com", Type: "A", Values: []string{"10.0.0.1"}}); err != nil {
+			t.Fatalf("Create %s: %v", host, err)
+		}
+	}
+
+	cname := dns.Record{Hostname: "app.example.com", Type: "CNAME", Values: []string{"gateway.example.com"},
+		Meta: map[string]string{"description": dns.ManagedDescription, dns.MetaOwner: "prod/default/web"}}
+	if err := p.Create(ctx, cname); err != nil {
+		t.Fatalf("Create CNAME: %v", err)
+	}
+
+	exists, err := p.Exists(ctx, "app.example.com", "CNAME")
+	if err != nil || !exists {
+		t.Fatalf("expected CNAME to exist, got %v, %v", exists, err)
+	}
+	if exists, _ := p.Exists(ctx, "app.example.com", "A"); exists {
+		t.Error("a CNAME must not be reported as an A record")
+	}
+
+	records, err := p.List(ctx, dns.ListFilter{Type: "CNAME"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 || !slices.Equal(records[0].Values, []string{"gateway.example.com"}) || records[0].Owner() != "prod/default/web" {
+		t.Fatalf("expected the alias as an owned CNAME to gateway.example.com, got %v", records)
+	}
+
+	cname.Values = []string{"edge.example.com"}
+	if err := p.Update(ctx, cname); err != nil {
+		t.Fatalf("Update CNAME: %v", err)
+	}
+	records, _ = p.List(ctx, dns.ListFilter{Hostname: "app.example.com"})
+	if len(records) != 1 || !slices.Equal(records[0].Values, []string{"edge.example.com"}) {
+		t.Fatalf("expected the alias to move to edge.example.com, got %v", records)
+	}
+
+	if err := p.Delete(ctx, "app.example.com", "CNAME"); err != nil {
+		t.Fatalf("Delete CNAME: %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.aliases) != 0 {
+		t.Errorf("expected alias to be deleted, got %v", fake.aliases)
+	}
+	if len(fake.store) != 2 {
+		t.Errorf("expected host overrides to be kept, got %d", len(fake.store))
+	}
+}
+
+func TestApplyChangesSwitchesToCNAME(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+	ctx := context.Background()
+
+	if err := p.Create(ctx, dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}}); err != nil {
+		t.Fatalf("Create app: %v", err)
+	}
+
+	// The CNAME target is created in the same batch.
+	err := p.ApplyChanges(ctx, dns.ChangeSet{
+		Creates: []dns.Record{
+			{Hostname: "app.example.com", Type: "CNAME", Values: []string{"gateway.example.com"}},
+			{Hostname: "gateway.example.com", Type: "A", Values: []string{"10.0.0.2"}},
+		},
+		Deletes: []dns.Record{
+			{Hostname: "app.example.com", Type: "A"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChanges: %v", err)
+	}
+
+	records, err := p.List(ctx, dns.ListFilter{Hostname: "app.example.com"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 || records[0].Type != "CNAME" || !slices.Equal(records[0].Values, []string{"gateway.example.com"}) {
+		t.Fatalf("expected only a CNAME to gateway.example.com, got %v", records)
+	}
+}
+
+func TestCNAMEWithoutTargetOverride(t *testing.T) {
+	fake := newFakeOPNsense()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newProvider(t, srv.URL)
+
+	err := p.Create(context.Background(), dns.Record{Hostname: "app.example.com", Type: "CNAME", Values: []string{"missing.example.com"}})
+	if err == nil || !strings.Contains(err.Error(), "has no host override") {
+		t.Fatalf("expected missing target error, got %v", err)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	record := dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1"}}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    []error
+		msg     string
+	}{
+		{
+			name: "rejected credentials",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+			},
+			want: []error{dns.ErrAuthFailed},
+			msg:  "returned status 401",
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+			},
+			want: []error{dns.ErrRetryable},
+			msg:  "returned status 503",
+		},
+		{
+			name: "validation failure",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/unbound/settings/addHostOverride" {
+					writeJSON(w, map[string]interface{}{"result": "failed", "validations": map[string]string{"host.server": "invalid address"}})
+					return
+				}
+				newFakeOPNsense().ServeHTTP(w, r)
+			},
+			want: []error{dns.ErrNonRetryable},
+			msg:  "addHostOverride unexpected result: failed (host.server: invalid address)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			err := newProvider(t, srv.URL).Create(context.Background(), record)
+			for _, target := range tt.want {
+				if !errors.Is(err, target) {
+					t.Errorf("expected %v, got %v", target, err)
+				}
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("expected an error containing %q, got %v", tt.msg, err)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		err := newProvider(t, srv.URL).HealthCheck(context.Background())
+		if !errors.Is(err, dns.ErrConnection) || !errors.Is(err, dns.ErrRetryable) {
+			t.Errorf("expected a retryable connection error, got %v", err)
+		}
+	})
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	fake := newFakeOPNsense()
+	var failed atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The second override of the record fails once, after the first was written.
+		fake.mu.Lock()
+		written := len(fake.store)
+		fake.mu.Unlock()
+		if r.URL.Path == "/api/unbound/settings/addHostOverride" && written == 1 && !failed.Swap(true) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	log := logrtesting.NewTestLogger(t)
+	p := dns.Chain(newProviderWith(t, log, srv.URL, nil),
+		dns.WithRetry(dns.RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}, log))
+
+	record := dns.Record{Hostname: "app.example.com", Type: "A", Values: []string{"10.0.0.1", "10.0.0.2"}}
+	if err := p.ApplyChanges(context.Background(), dns.ChangeSet{Creates: []dns.Record{record}}); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if !failed.Load() {
+		t.Fatal("expected the first attempt to fail")
+	}
+
+	var servers []string
+	for _, o := range fake.store {
+		servers = append(servers, o.Server)
+	}
+	slices.Sort(servers)
+	if !slices.Equal(servers, record.Values) {
+		t.Errorf("expected one override per value without duplicates, got %v", servers)
+	}
+}